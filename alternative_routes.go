@@ -0,0 +1,249 @@
+package ch
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// AlternativeOptions Admissibility criteria of alternative routes. See AlternativeRoutes(...)
+//
+// Zero value of every field means default one.
+//
+// MaxAlternatives - Maximum number of alternative routes (besides the main one). Default is 2
+// MaxSharing - Maximum ratio of cost shared with the main route and previously found alternatives to cost of the main route (limited sharing). Default is 0.8
+// MaxStretch - Maximum excess of cost over cost of the main route, e.g. 0.25 means 25% longer (bounded stretch). Default is 0.25
+// LocalOptimality - Ratio of cost of the main route: every subpath of alternative route around via-node which is shorter than that must be a shortest path (local optimality). Default is 0.25
+type AlternativeOptions struct {
+	MaxAlternatives int
+	MaxSharing      float64
+	MaxStretch      float64
+	LocalOptimality float64
+}
+
+// AlternativeRoute Route found by AlternativeRoutes(...)
+//
+// Cost - Cost of route
+// Path - User's defined IDs of vertices of route
+// Sharing - Ratio of cost shared with the main route to cost of the main route (1 for the main route itself)
+type AlternativeRoute struct {
+	Cost    float64
+	Path    []int64
+	Sharing float64
+}
+
+// withDefaults Returns options where zero values are replaced by defaults
+func (opts AlternativeOptions) withDefaults() AlternativeOptions {
+	if opts.MaxAlternatives <= 0 {
+		opts.MaxAlternatives = 2
+	}
+	if opts.MaxSharing <= 0 {
+		opts.MaxSharing = 0.8
+	}
+	if opts.MaxStretch <= 0 {
+		opts.MaxStretch = 0.25
+	}
+	if opts.LocalOptimality <= 0 {
+		opts.LocalOptimality = 0.25
+	}
+	return opts
+}
+
+// AlternativeRoutes Computes the shortest route and meaningfully different alternative routes (via-node method)
+//
+// If there are some errors (or there is no path at all, or CH has not been prepared) then function returns nil.
+//
+// source - User's definied ID of source vertex
+// target - User's definied ID of target vertex
+// opts - Admissibility criteria of alternative routes (see AlternativeOptions)
+//
+// The first route is the main (shortest) one. Forward and backward searches in contraction hierarchies are run without stopping criterion (but with cost limit given by stretch).
+// Every vertex settled by both searches is a via-node candidate: route source -> via -> target is admissible if it is loopless, it has bounded stretch,
+// it has limited sharing with the main route and with previously accepted alternatives and it is locally optimal (checked by CH query between vertices around via-node: T-test).
+// Candidates are checked in ascending order of cost.
+// Since via-nodes are taken from CH search spaces only, alternative route which passes unimportant vertices only could be missed.
+//
+// Reference: I. Abraham, D. Delling, A. V. Goldberg, R. F. Werneck. Alternative Routes in Road Networks. https://doi.org/10.1145/2444016.2444019
+func (graph *Graph) AlternativeRoutes(source, target int64, opts AlternativeOptions) []AlternativeRoute {
+	if !graph.chPrepared {
+		return nil
+	}
+	opts = opts.withDefaults()
+	sourceInternal, ok := graph.mapping[source]
+	if !ok {
+		return nil
+	}
+	targetInternal, ok := graph.mapping[target]
+	if !ok {
+		return nil
+	}
+	mainCost, mainLabels := graph.shortestPath([directionsCount]int64{sourceInternal, targetInternal})
+	if mainLabels == nil {
+		return nil
+	}
+	routes := []AlternativeRoute{{Cost: mainCost, Path: mainLabels, Sharing: 1}}
+	if sourceInternal == targetInternal {
+		return routes
+	}
+	mainPath := graph.internalPath(mainLabels)
+	mainEdges := graph.pathEdges(mainPath)
+	mainVertices := make(map[int64]struct{}, len(mainPath))
+	for _, vertex := range mainPath {
+		mainVertices[vertex] = struct{}{}
+	}
+	// Edges of the main route and of accepted alternatives
+	usedEdges := make(map[EdgeKey]struct{}, len(mainEdges))
+	for edge := range mainEdges {
+		usedEdges[edge] = struct{}{}
+	}
+
+	maxCost := mainCost * (1 + opts.MaxStretch)
+	forwardDist, forwardPrev := graph.upwardSearch(sourceInternal, forward, maxCost)
+	backwardDist, backwardPrev := graph.upwardSearch(targetInternal, backward, maxCost)
+	type viaCandidate struct {
+		vertex int64
+		cost   float64
+	}
+	candidates := make([]viaCandidate, 0)
+	for vertex, dist := range forwardDist {
+		if reverse, ok := backwardDist[vertex]; ok && dist+reverse <= maxCost {
+			candidates = append(candidates, viaCandidate{vertex: vertex, cost: dist + reverse})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].cost == candidates[j].cost {
+			return candidates[i].vertex < candidates[j].vertex
+		}
+		return candidates[i].cost < candidates[j].cost
+	})
+
+	for _, candidate := range candidates {
+		if len(routes) > opts.MaxAlternatives {
+			break
+		}
+		if _, ok := mainVertices[candidate.vertex]; ok {
+			// Via-node on the main route gives the main route itself
+			continue
+		}
+		labels := graph.ComputePath(candidate.vertex, forwardPrev, backwardPrev)
+		path := graph.internalPath(labels)
+		edges := graph.pathEdges(path)
+		if edges == nil {
+			// Route has loop
+			continue
+		}
+		routeCost, shared, sharedWithMain := 0.0, 0.0, 0.0
+		for edge, cost := range edges {
+			routeCost += cost
+			if _, ok := usedEdges[edge]; ok {
+				shared += cost
+			}
+			if _, ok := mainEdges[edge]; ok {
+				sharedWithMain += cost
+			}
+		}
+		if routeCost > maxCost || shared > opts.MaxSharing*mainCost {
+			continue
+		}
+		if !graph.locallyOptimal(path, candidate.vertex, opts.LocalOptimality*mainCost) {
+			continue
+		}
+		for edge := range edges {
+			usedEdges[edge] = struct{}{}
+		}
+		routes = append(routes, AlternativeRoute{Cost: routeCost, Path: labels, Sharing: sharedWithMain / mainCost})
+	}
+	return routes
+}
+
+// upwardSearch Dijkstra's algorithm on upward arcs of contraction hierarchies (in given direction) from vertex (library defined ID) without stopping criterion.
+// Vertices farther than maxCost are not settled. Returns distances and previous vertices of settled ones
+func (graph *Graph) upwardSearch(source int64, d direction, maxCost float64) (map[int64]float64, map[int64]int64) {
+	distance := map[int64]float64{source: 0}
+	prev := make(map[int64]int64)
+	queue := &minHeap{}
+	queue.add_with_priority(source, 0)
+	for queue.Len() != 0 {
+		u := heap.Pop(queue).(*minHeapVertex)
+		if u.distance > distance[u.id] {
+			// Outdated item of queue
+			continue
+		}
+		vertexList := graph.Vertices[u.id].outIncidentEdges
+		if d == backward {
+			vertexList = graph.Vertices[u.id].inIncidentEdges
+		}
+		for _, edge := range vertexList {
+			neighbor := edge.vertexID
+			if graph.Vertices[u.id].orderPos >= graph.Vertices[neighbor].orderPos {
+				continue
+			}
+			alt := u.distance + edge.weight
+			if alt > maxCost {
+				continue
+			}
+			if dist, ok := distance[neighbor]; !ok || alt < dist {
+				distance[neighbor] = alt
+				prev[neighbor] = u.id
+				queue.add_with_priority(neighbor, alt)
+			}
+		}
+	}
+	return distance, prev
+}
+
+// locallyOptimal Checks if subpath of path around via vertex (library defined IDs) is the shortest path (T-test).
+// Subpath starts at the first vertex which is at least minCost before via vertex and ends at the first vertex which is at least minCost after it
+func (graph *Graph) locallyOptimal(path []int64, via int64, minCost float64) bool {
+	viaPos := -1
+	for i, vertex := range path {
+		if vertex == via {
+			viaPos = i
+			break
+		}
+	}
+	if viaPos < 0 {
+		return false
+	}
+	first, last := viaPos, viaPos
+	subpathCost := 0.0
+	for cost := 0.0; first > 0 && cost < minCost; first-- {
+		edgeCost := graph.originalEdgeCost(path[first-1], path[first])
+		cost += edgeCost
+		subpathCost += edgeCost
+	}
+	for cost := 0.0; last+1 < len(path) && cost < minCost; last++ {
+		edgeCost := graph.originalEdgeCost(path[last], path[last+1])
+		cost += edgeCost
+		subpathCost += edgeCost
+	}
+	if first == last {
+		return true
+	}
+	shortest, _ := graph.shortestPath([directionsCount]int64{path[first], path[last]})
+	return subpathCost <= shortest+1e-9*subpathCost
+}
+
+// internalPath Converts user's defined IDs of vertices of path to library defined ones
+func (graph *Graph) internalPath(labels []int64) []int64 {
+	path := make([]int64, len(labels))
+	for i, label := range labels {
+		path[i] = graph.mapping[label]
+	}
+	return path
+}
+
+// pathEdges Returns edges of path (library defined IDs of vertices) with their costs. Returns nil if path visits some vertex twice
+func (graph *Graph) pathEdges(path []int64) map[EdgeKey]float64 {
+	edges := make(map[EdgeKey]float64, len(path))
+	visited := make(map[int64]struct{}, len(path))
+	for i, vertex := range path {
+		if _, ok := visited[vertex]; ok {
+			return nil
+		}
+		visited[vertex] = struct{}{}
+		if i > 0 {
+			edges[EdgeKey{From: path[i-1], To: vertex}] = graph.originalEdgeCost(path[i-1], vertex)
+		}
+	}
+	return edges
+}
@@ -0,0 +1,87 @@
+package ch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlternativeRoutes(t *testing.T) {
+	g := NewGraph()
+	for i := int64(0); i < 10; i++ {
+		g.CreateVertex(i)
+	}
+	// Main route 0 -> 1 -> 2 -> 9 (cost 10)
+	g.AddEdge(0, 1, 4.25)
+	g.AddEdge(1, 2, 4.25)
+	g.AddEdge(2, 9, 1.5)
+	// Detour 2 -> 6 -> 9 shares too much with the main route (cost 11)
+	g.AddEdge(2, 6, 1)
+	g.AddEdge(6, 9, 1.5)
+	// Disjoint route 0 -> 3 -> 4 -> 9 (cost 11)
+	g.AddEdge(0, 3, 4)
+	g.AddEdge(3, 4, 4)
+	g.AddEdge(4, 9, 3)
+	// Route 0 -> 7 -> 9 is too long (cost 14)
+	g.AddEdge(0, 7, 7)
+	g.AddEdge(7, 9, 7)
+	g.PrepareContractionHierarchies()
+
+	routes := g.AlternativeRoutes(0, 9, AlternativeOptions{MaxAlternatives: 3})
+	if assert.Len(t, routes, 2) {
+		assert.Equal(t, AlternativeRoute{Cost: 10, Path: []int64{0, 1, 2, 9}, Sharing: 1}, routes[0])
+		assert.Equal(t, AlternativeRoute{Cost: 11, Path: []int64{0, 3, 4, 9}, Sharing: 0}, routes[1])
+	}
+	// Disjoint route is too long
+	routes = g.AlternativeRoutes(0, 9, AlternativeOptions{MaxStretch: 0.05})
+	assert.Len(t, routes, 1)
+	routes = g.AlternativeRoutes(0, 9, AlternativeOptions{MaxAlternatives: 1})
+	assert.Len(t, routes, 2)
+	routes = g.AlternativeRoutes(0, 0, AlternativeOptions{})
+	assert.Equal(t, []AlternativeRoute{{Cost: 0, Path: []int64{0}, Sharing: 1}}, routes)
+
+	assert.Nil(t, g.AlternativeRoutes(9, 0, AlternativeOptions{}))
+	assert.Nil(t, g.AlternativeRoutes(0, 100, AlternativeOptions{}))
+}
+
+func TestAlternativeRoutesGrid(t *testing.T) {
+	const size = 8
+	g := generateGridGraph(size)
+	g.PrepareContractionHierarchies()
+	opts := AlternativeOptions{MaxAlternatives: 3}.withDefaults()
+	found := 0
+	for source := int64(0); source < size*size; source += 7 {
+		for target := int64(0); target < size*size; target += 5 {
+			if source == target {
+				continue
+			}
+			routes := g.AlternativeRoutes(source, target, opts)
+			expectedCost, expectedPath := g.ShortestPath(source, target)
+			if !assert.NotEmpty(t, routes) {
+				return
+			}
+			assert.InDelta(t, expectedCost, routes[0].Cost, eps)
+			assert.Equal(t, expectedPath, routes[0].Path)
+			assert.LessOrEqual(t, len(routes), opts.MaxAlternatives+1)
+			found += len(routes) - 1
+			for _, route := range routes[1:] {
+				path := g.internalPath(route.Path)
+				edges := g.pathEdges(path)
+				if !assert.NotNil(t, edges, "Route %v has loop", route.Path) {
+					return
+				}
+				assert.Equal(t, source, route.Path[0])
+				assert.Equal(t, target, route.Path[len(route.Path)-1])
+				cost := 0.0
+				for _, edgeCost := range edges {
+					assert.True(t, edgeCost >= 0, "Route %v has unknown edge", route.Path)
+					cost += edgeCost
+				}
+				assert.InDelta(t, cost, route.Cost, eps)
+				assert.LessOrEqual(t, route.Cost, expectedCost*(1+opts.MaxStretch)+eps)
+				assert.LessOrEqual(t, route.Sharing, opts.MaxSharing+eps)
+			}
+		}
+	}
+	assert.NotZero(t, found)
+}
@@ -358,6 +358,16 @@ func graphFromCSV(graph *Graph, fname string) error {
 }
 
 func generateSyntheticGraph(verticesNum int) (*Graph, error) {
+	graph, err := buildSyntheticGraph(verticesNum)
+	if err != nil {
+		return nil, err
+	}
+	graph.PrepareContractionHierarchies()
+	return graph, nil
+}
+
+// buildSyntheticGraph Returns the same graph as generateSyntheticGraph(...), but without contraction hierarchies
+func buildSyntheticGraph(verticesNum int) (*Graph, error) {
 	rand.Seed(1337)
 	graph := Graph{}
 	var i int
@@ -392,7 +402,6 @@ func generateSyntheticGraph(verticesNum int) (*Graph, error) {
 			}
 		}
 	}
-	graph.PrepareContractionHierarchies()
 	return &graph, nil
 }
 
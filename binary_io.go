@@ -0,0 +1,118 @@
+package ch
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// binaryWriter Little-endian encoder with sticky error (no need to check error after every single value)
+type binaryWriter struct {
+	w   io.Writer
+	buf [8]byte
+	err error
+}
+
+func newBinaryWriter(w io.Writer) *binaryWriter {
+	return &binaryWriter{w: w}
+}
+
+func (bw *binaryWriter) write(p []byte) {
+	if bw.err != nil {
+		return
+	}
+	_, bw.err = bw.w.Write(p)
+}
+
+func (bw *binaryWriter) uint8(v uint8) {
+	bw.buf[0] = v
+	bw.write(bw.buf[:1])
+}
+
+func (bw *binaryWriter) uint16(v uint16) {
+	binary.LittleEndian.PutUint16(bw.buf[:2], v)
+	bw.write(bw.buf[:2])
+}
+
+func (bw *binaryWriter) uint32(v uint32) {
+	binary.LittleEndian.PutUint32(bw.buf[:4], v)
+	bw.write(bw.buf[:4])
+}
+
+func (bw *binaryWriter) uint64(v uint64) {
+	binary.LittleEndian.PutUint64(bw.buf[:8], v)
+	bw.write(bw.buf[:8])
+}
+
+func (bw *binaryWriter) int64(v int64) {
+	bw.uint64(uint64(v))
+}
+
+func (bw *binaryWriter) float64(v float64) {
+	bw.uint64(math.Float64bits(v))
+}
+
+// binaryReader Little-endian decoder with sticky error (no need to check error after every single value)
+type binaryReader struct {
+	r   io.Reader
+	buf [8]byte
+	err error
+}
+
+func newBinaryReader(r io.Reader) *binaryReader {
+	return &binaryReader{r: r}
+}
+
+func (br *binaryReader) read(p []byte) {
+	if br.err != nil {
+		return
+	}
+	_, br.err = io.ReadFull(br.r, p)
+}
+
+func (br *binaryReader) uint8() uint8 {
+	br.read(br.buf[:1])
+	if br.err != nil {
+		return 0
+	}
+	return br.buf[0]
+}
+
+func (br *binaryReader) uint16() uint16 {
+	br.read(br.buf[:2])
+	if br.err != nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(br.buf[:2])
+}
+
+func (br *binaryReader) uint32() uint32 {
+	br.read(br.buf[:4])
+	if br.err != nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(br.buf[:4])
+}
+
+func (br *binaryReader) uint64() uint64 {
+	br.read(br.buf[:8])
+	if br.err != nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(br.buf[:8])
+}
+
+func (br *binaryReader) int64() int64 {
+	return int64(br.uint64())
+}
+
+func (br *binaryReader) float64() float64 {
+	return math.Float64frombits(br.uint64())
+}
+
+func boolToUint8(v bool) uint8 {
+	if v {
+		return 1
+	}
+	return 0
+}
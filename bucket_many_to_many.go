@@ -0,0 +1,105 @@
+package ch
+
+// bucketEntry Cost of backward upward search from target (index of target) to vertex
+type bucketEntry struct {
+	targetIdx int
+	cost      float64
+}
+
+// ShortestPathManyToManyBuckets Computes and returns shortest paths and theirs's costs between multiple sources and targets (bucket-based many-to-many algorithm)
+//
+// If there are some errors then function returns '-1.0' as cost and nil as shortest path
+//
+// sources - set of user's definied IDs of source vertices
+// targets - set of user's definied IDs of target vertices
+//
+// Results are the same as for ShortestPathManyToMany(...), but memory is proportional to search spaces of endpoints instead of number of endpoints multiplied by number of vertices:
+// backward upward searches from targets fill buckets of visited vertices, then forward upward searches from sources scan buckets of visited vertices.
+// Function does not touch shared query state of graph, so it could be called concurrently (while graph is not modified).
+// If there are several shortest paths then middle vertex with the lowest position in hierarchies is taken, so paths are deterministic.
+//
+// Reference: S. Knopp, P. Sanders, D. Schultes, F. Schulz, D. Wagner. Computing Many-to-Many Shortest Paths Using Highway Hierarchies. https://doi.org/10.1137/1.9781611972870.4
+func (graph *Graph) ShortestPathManyToManyBuckets(sources, targets []int64) ([][]float64, [][][]int64) {
+	return graph.shortestPathManyToManyBuckets(sources, targets, true)
+}
+
+// ShortestPathManyToManyDistances Computes and returns costs of shortest paths between multiple sources and targets (bucket-based many-to-many algorithm) without paths reconstruction
+//
+// If there are some errors then function returns '-1.0' as cost
+//
+// sources - set of user's definied IDs of source vertices
+// targets - set of user's definied IDs of target vertices
+//
+// See ShortestPathManyToManyBuckets(...) for details.
+func (graph *Graph) ShortestPathManyToManyDistances(sources, targets []int64) [][]float64 {
+	costs, _ := graph.shortestPathManyToManyBuckets(sources, targets, false)
+	return costs
+}
+
+// lowerInHierarchies Checks if vertex (library defined ID) has lower position in hierarchies than other one (-1 means no vertex)
+func (graph *Graph) lowerInHierarchies(vertex, other int64) bool {
+	return other < 0 || graph.Vertices[vertex].orderPos < graph.Vertices[other].orderPos
+}
+
+func (graph *Graph) shortestPathManyToManyBuckets(sources, targets []int64, withPaths bool) ([][]float64, [][][]int64) {
+	buckets := make(map[int64][]bucketEntry)
+	var backwardPrevs []map[int64]int64
+	if withPaths {
+		backwardPrevs = make([]map[int64]int64, len(targets))
+	}
+	for targetIdx, target := range targets {
+		targetInternal, ok := graph.mapping[target]
+		if !ok {
+			continue
+		}
+		distance, prev := graph.upwardSearch(targetInternal, backward, Infinity)
+		for vertex, cost := range distance {
+			buckets[vertex] = append(buckets[vertex], bucketEntry{targetIdx: targetIdx, cost: cost})
+		}
+		if withPaths {
+			backwardPrevs[targetIdx] = prev
+		}
+	}
+
+	costs := make([][]float64, len(sources))
+	var paths [][][]int64
+	if withPaths {
+		paths = make([][][]int64, len(sources))
+	}
+	middleIDs := make([]int64, len(targets))
+	for sourceIdx, source := range sources {
+		costs[sourceIdx] = make([]float64, len(targets))
+		for targetIdx := range targets {
+			costs[sourceIdx][targetIdx] = Infinity
+			middleIDs[targetIdx] = -1
+		}
+		var forwardPrev map[int64]int64
+		if sourceInternal, ok := graph.mapping[source]; ok {
+			var distance map[int64]float64
+			distance, forwardPrev = graph.upwardSearch(sourceInternal, forward, Infinity)
+			for vertex, cost := range distance {
+				for _, entry := range buckets[vertex] {
+					alt := cost + entry.cost
+					// Vertices are scanned in random order (map iteration), so ties are broken by position in hierarchies
+					if alt < costs[sourceIdx][entry.targetIdx] || (alt == costs[sourceIdx][entry.targetIdx] && graph.lowerInHierarchies(vertex, middleIDs[entry.targetIdx])) {
+						costs[sourceIdx][entry.targetIdx] = alt
+						middleIDs[entry.targetIdx] = vertex
+					}
+				}
+			}
+		}
+		if withPaths {
+			paths[sourceIdx] = make([][]int64, len(targets))
+		}
+		for targetIdx := range targets {
+			if costs[sourceIdx][targetIdx] == Infinity {
+				costs[sourceIdx][targetIdx] = -1
+				continue
+			}
+			if withPaths {
+				paths[sourceIdx][targetIdx] = graph.ComputePath(middleIDs[targetIdx], forwardPrev, backwardPrevs[targetIdx])
+			}
+		}
+	}
+	return costs, paths
+}
@@ -0,0 +1,85 @@
+package ch
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShortestPathManyToManyBuckets(t *testing.T) {
+	const size = 10
+	g := generateGridGraph(size)
+	// Vertex without edges
+	g.CreateVertex(size * size)
+	g.PrepareContractionHierarchies()
+
+	sources := []int64{0, 13, 13, 57, 99, size * size, 1000}
+	targets := []int64{5, 13, 42, 90, 99, size * size, 1000}
+	costs, paths := g.ShortestPathManyToManyBuckets(sources, targets)
+	distances := g.ShortestPathManyToManyDistances(sources, targets)
+	assert.Equal(t, costs, distances)
+	if !assert.Len(t, costs, len(sources)) || !assert.Len(t, paths, len(sources)) {
+		return
+	}
+	for sourceIdx, source := range sources {
+		for targetIdx, target := range targets {
+			expectedCost := -1.0
+			if source == target && source <= size*size {
+				expectedCost = 0
+			} else if source < size*size && target < size*size {
+				expectedCost, _ = g.VanillaShortestPath(source, target)
+			}
+			if math.Abs(expectedCost-costs[sourceIdx][targetIdx]) > eps {
+				t.Errorf("Cost of path %d -> %d should be %f, but got %f", source, target, expectedCost, costs[sourceIdx][targetIdx])
+				return
+			}
+			path := paths[sourceIdx][targetIdx]
+			if expectedCost < 0 {
+				assert.Nil(t, path)
+				continue
+			}
+			if !assert.NotEmpty(t, path) {
+				return
+			}
+			assert.Equal(t, source, path[0])
+			assert.Equal(t, target, path[len(path)-1])
+			pathCost := 0.0
+			for i := 1; i < len(path); i++ {
+				from, _ := g.FindVertex(path[i-1])
+				to, _ := g.FindVertex(path[i])
+				pathCost += g.originalEdgeCost(from, to)
+			}
+			assert.InDelta(t, expectedCost, pathCost, eps)
+		}
+	}
+}
+
+func TestShortestPathManyToManyBucketsTies(t *testing.T) {
+	// Every edge has the same weight, so there are many shortest paths between corners of grid
+	const size = 6
+	g := NewGraph()
+	for v := int64(0); v < size*size; v++ {
+		g.CreateVertex(v)
+	}
+	for v := int64(0); v < size*size; v++ {
+		if v%size+1 < size {
+			g.AddEdge(v, v+1, 1)
+			g.AddEdge(v+1, v, 1)
+		}
+		if v+size < size*size {
+			g.AddEdge(v, v+size, 1)
+			g.AddEdge(v+size, v, 1)
+		}
+	}
+	g.PrepareContractionHierarchies()
+	sources := []int64{0, size - 1}
+	targets := []int64{size*size - 1, size * (size - 1)}
+	_, expectedPaths := g.ShortestPathManyToManyBuckets(sources, targets)
+	for i := 0; i < 20; i++ {
+		_, paths := g.ShortestPathManyToManyBuckets(sources, targets)
+		if !assert.Equal(t, expectedPaths, paths) {
+			return
+		}
+	}
+}
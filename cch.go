@@ -0,0 +1,399 @@
+package ch
+
+import (
+	"sort"
+)
+
+const (
+	// cchUp Direction of hierarchy arc from lower ranked vertex to upper one
+	cchUp = 0
+	// cchDown Direction of hierarchy arc from upper ranked vertex to lower one
+	cchDown = 1
+)
+
+// customizableHierarchy Metric-independent part of customizable contraction hierarchies (CCH) and current customization of it
+//
+// Arcs are edges of chordal supergraph of graph (for given contraction order) or just pairs of adjacent vertices of already prepared hierarchies (see buildTriangleHierarchy()):
+// every arc connects lower ranked vertex with upper ranked one.
+// Every arc has cost and middle vertex (-1 if arc is the initial edge) for both directions: cchUp and cchDown.
+type customizableHierarchy struct {
+	// Ranks of vertices (the same as orderPos)
+	rank []int64
+	// Arcs of vertex v are head[firstArc[v]:firstArc[v+1]], sorted by rank of upper vertex
+	firstArc []int64
+	head     []int64
+	cost     [2][]float64
+	via      [2][]int64
+	// Positions of incident edges which represent arcs (-1 if there is no such edge yet).
+	// outPos[d][arc] is index in outIncidentEdges of source of arc in direction d, inPos[d][arc] is index in inIncidentEdges of target of arc in direction d
+	outPos [2][]int
+	inPos  [2][]int
+	// Number of initial outcoming edges of every vertex: incident edges which represent arcs are appended after initial ones
+	originalOutDegree []int
+	// Number of initial incoming edges of every vertex: incident edges which represent arcs are appended after initial ones
+	originalInDegree []int
+	// Buffers for the next customization (swapped with cost and via)
+	spareCost [2][]float64
+	spareVia  [2][]int64
+	// Arcs by their upper vertex (lazily built for incremental customization, see buildLowerArcs(...)):
+	// lower vertices of u are lowerTail[lowerFirst[u]:lowerFirst[u+1]] and lowerArc keeps corresponding arcs
+	lowerFirst []int64
+	lowerTail  []int64
+	lowerArc   []int64
+	// Offsets of initial edges of vertices in flat array of weights (lazily built, see edgeOffsets())
+	initialEdgeOffsets []int64
+}
+
+// PrepareCustomizable Computes customizable contraction hierarchies (CCH) with contraction order given by nested dissection. See NestedDissectionOrder()
+//
+// Unlike PrepareContractionHierarchies() there are no witness searches: for every contracted vertex all pairs of its upper neighbors are connected.
+// So the set of shortcuts does not depend on weights of edges, and any metric could be applied later via UpdateEdgeWeight(...) and Recustomize():
+// shortest paths stay exact for arbitrary increases and decreases of weights.
+//
+// CSV and binary formats don't store customizable hierarchies: ExportToFile(...), ExportBinary(...) and similar functions return error for such graph.
+//
+// Returns error if CH has been prepared already or if graph has no vertices.
+func (graph *Graph) PrepareCustomizable() error {
+	if err := graph.checkCustomizablePreparation(); err != nil {
+		return err
+	}
+	graph.prepareCustomizable(graph.nestedDissectionOrder())
+	return nil
+}
+
+// PrepareCustomizableWithOrder Computes customizable contraction hierarchies (CCH) with given contraction order. See PrepareCustomizable()
+//
+// order - User's defined IDs of vertices in the order of contraction. Every vertex of graph must be presented exactly once.
+//
+// Returns error if CH has been prepared already, if graph has no vertices or if order is invalid.
+func (graph *Graph) PrepareCustomizableWithOrder(order []int64) error {
+	if err := graph.checkCustomizablePreparation(); err != nil {
+		return err
+	}
+	internalOrder, err := graph.internalOrder(order)
+	if err != nil {
+		return err
+	}
+	graph.prepareCustomizable(internalOrder)
+	return nil
+}
+
+// checkCustomizablePreparation Checks if customizable contraction hierarchies could be prepared for graph
+func (graph *Graph) checkCustomizablePreparation() error {
+	if graph.chPrepared {
+		return ErrCHAlreadyPrepared
+	}
+	if len(graph.Vertices) == 0 {
+		return ErrEmptyGraph
+	}
+	return nil
+}
+
+// prepareCustomizable Builds chordal supergraph for given order (library defined IDs of vertices) and customizes it with current weights
+func (graph *Graph) prepareCustomizable(order []int64) {
+	graph.Freeze()
+	graph.contractionOrder = make([]int64, 0, len(order))
+	for i, vertexNum := range order {
+		vertex := &graph.Vertices[vertexNum]
+		vertex.orderPos = int64(i)
+		vertex.importance = i
+		vertex.contracted = true
+		graph.contractionOrder = append(graph.contractionOrder, vertexNum)
+	}
+	graph.cch = newCustomizableHierarchy(graph)
+	graph.snapshotTopologyChanged = true
+	graph.customize(false)
+
+	// Mark CH as prepared
+	graph.chPrepared = true
+}
+
+// newCustomizableHierarchy Builds chordal supergraph of graph for current order of vertices
+//
+// Elimination game: once vertex is contracted, all of its upper neighbors become pairwise adjacent.
+// It is enough to pass upper neighbors to the lowest ranked one of them only, since it passes them further when it is contracted.
+func newCustomizableHierarchy(graph *Graph) *customizableHierarchy {
+	n := len(graph.Vertices)
+	cch := &customizableHierarchy{
+		rank:              make([]int64, n),
+		firstArc:          make([]int64, n+1),
+		originalOutDegree: make([]int, n),
+		originalInDegree:  make([]int, n),
+	}
+	for i := range graph.Vertices {
+		cch.rank[i] = graph.Vertices[i].orderPos
+		cch.originalOutDegree[i] = len(graph.Vertices[i].outIncidentEdges)
+		cch.originalInDegree[i] = len(graph.Vertices[i].inIncidentEdges)
+	}
+
+	upper := graph.undirectedAdjacency()
+	for v := range upper {
+		neighbors := upper[v][:0]
+		for _, u := range upper[v] {
+			if cch.rank[u] > cch.rank[v] {
+				neighbors = append(neighbors, u)
+			}
+		}
+		upper[v] = neighbors
+	}
+	for _, v := range graph.contractionOrder {
+		unique := cch.sortByRank(upper[v])
+		upper[v] = unique
+		if len(unique) > 1 {
+			parent := unique[0]
+			upper[parent] = append(upper[parent], unique[1:]...)
+		}
+	}
+
+	cch.setArcs(upper)
+	return cch
+}
+
+// setArcs Fills arcs by upper neighbors (sorted by rank) of every vertex. Arcs get no cost and no incident edges.
+func (cch *customizableHierarchy) setArcs(upper [][]int64) {
+	for v := range upper {
+		cch.firstArc[v+1] = cch.firstArc[v] + int64(len(upper[v]))
+	}
+	arcsNum := cch.firstArc[len(upper)]
+	cch.head = make([]int64, 0, arcsNum)
+	for v := range upper {
+		cch.head = append(cch.head, upper[v]...)
+	}
+	for d := range cch.cost {
+		cch.cost[d] = make([]float64, arcsNum)
+		cch.via[d] = make([]int64, arcsNum)
+		cch.outPos[d] = make([]int, arcsNum)
+		cch.inPos[d] = make([]int, arcsNum)
+		for arc := range cch.cost[d] {
+			cch.cost[d][arc] = Infinity
+			cch.via[d][arc] = -1
+			cch.outPos[d][arc] = -1
+			cch.inPos[d][arc] = -1
+		}
+	}
+}
+
+// sortByRank Sorts vertices by rank and removes duplicates
+func (cch *customizableHierarchy) sortByRank(vertices []int64) []int64 {
+	sort.Slice(vertices, func(i, j int) bool { return cch.rank[vertices[i]] < cch.rank[vertices[j]] })
+	unique := vertices[:0]
+	for k, u := range vertices {
+		if k > 0 && u == vertices[k-1] {
+			continue
+		}
+		unique = append(unique, u)
+	}
+	return unique
+}
+
+// findArc Returns index of arc between lower ranked vertex and upper ranked one (-1 if there is no such arc)
+func (cch *customizableHierarchy) findArc(lower, upper int64) int64 {
+	first, last := cch.firstArc[lower], cch.firstArc[lower+1]
+	rank := cch.rank[upper]
+	idx := first + int64(sort.Search(int(last-first), func(i int) bool {
+		return cch.rank[cch.head[first+int64(i)]] >= rank
+	}))
+	if idx == last || cch.head[idx] != upper {
+		return -1
+	}
+	return idx
+}
+
+// customize Computes costs of all arcs of hierarchies for current weights of initial edges and applies them to graph.
+// Returns arcs which cost or middle vertex has been changed if report is true.
+//
+// Arcs get costs of initial edges first. Then vertices are processed in contraction order: every lower triangle (w, v, u) where w is lower than v and v is lower than u
+// gives path v→w→u (and u→w→v) which could be shorter than arc between v and u. Arcs of w are final once w is processed, since all triangles below them have been processed already.
+func (graph *Graph) customize(report bool) []AffectedPair {
+	cch := graph.cch
+	var previousCost [2][]float64
+	var previousVia [2][]int64
+	if report {
+		previousCost, previousVia = graph.queriedArcs()
+	}
+	cost, via := cch.spareCost, cch.spareVia
+	for d := range cost {
+		if cost[d] == nil {
+			cost[d] = make([]float64, len(cch.head))
+			via[d] = make([]int64, len(cch.head))
+		}
+	}
+	graph.customizeCosts(cost, via, nil)
+
+	cch.spareCost, cch.cost = cch.cost, cost
+	cch.spareVia, cch.via = cch.via, via
+	graph.applyCustomization()
+	if report {
+		return graph.affectedPairs(previousCost, previousVia)
+	}
+	return nil
+}
+
+// customizeCosts Computes costs and middle vertices of all arcs of hierarchies (see customize()) without applying them to graph
+//
+// weights - Weights of initial edges indexed by edgeOffsets() (nil means current weights of incident edges)
+func (graph *Graph) customizeCosts(cost [2][]float64, via [2][]int64, weights []float64) {
+	cch := graph.cch
+	for d := range cost {
+		for arc := range cost[d] {
+			cost[d][arc] = Infinity
+			via[d][arc] = -1
+		}
+	}
+
+	var offsets []int64
+	if weights != nil {
+		offsets = cch.edgeOffsets()
+	}
+	for v := range graph.Vertices {
+		vertexNum := int64(v)
+		for j, edge := range graph.Vertices[v].outIncidentEdges[:cch.originalOutDegree[v]] {
+			if edge.vertexID == vertexNum {
+				continue
+			}
+			if weights != nil {
+				edge.weight = weights[offsets[v]+int64(j)]
+			}
+			lower, upper, d := vertexNum, edge.vertexID, cchUp
+			if cch.rank[upper] < cch.rank[lower] {
+				lower, upper, d = upper, lower, cchDown
+			}
+			arc := cch.findArc(lower, upper)
+			if edge.weight < cost[d][arc] {
+				cost[d][arc] = edge.weight
+			}
+		}
+	}
+
+	for _, w := range graph.contractionOrder {
+		first, last := cch.firstArc[w], cch.firstArc[w+1]
+		for i := first; i < last; i++ {
+			v := cch.head[i]
+			for j := i + 1; j < last; j++ {
+				u := cch.head[j]
+				// Arc always exists in chordal supergraph, but not in hierarchies prepared with witness searches
+				arc := cch.findArc(v, u)
+				if arc < 0 {
+					continue
+				}
+				// v→w→u
+				if c := cost[cchDown][i] + cost[cchUp][j]; c < cost[cchUp][arc] {
+					cost[cchUp][arc] = c
+					via[cchUp][arc] = w
+				}
+				// u→w→v
+				if c := cost[cchDown][j] + cost[cchUp][i]; c < cost[cchDown][arc] {
+					cost[cchDown][arc] = c
+					via[cchDown][arc] = w
+				}
+			}
+		}
+	}
+}
+
+// edgeOffsets Returns offsets of initial edges of every vertex in flat array of all initial edges: j-th initial outcoming edge of vertex v has index offsets[v]+j
+func (cch *customizableHierarchy) edgeOffsets() []int64 {
+	if cch.initialEdgeOffsets == nil {
+		offsets := make([]int64, len(cch.originalOutDegree)+1)
+		for v, degree := range cch.originalOutDegree {
+			offsets[v+1] = offsets[v] + int64(degree)
+		}
+		cch.initialEdgeOffsets = offsets
+	}
+	return cch.initialEdgeOffsets
+}
+
+// applyCustomization Stores costs of arcs of customizable contraction hierarchies in incident edges and shortcuts of graph
+func (graph *Graph) applyCustomization() {
+	cch := graph.cch
+	for v := range graph.Vertices {
+		for arc := cch.firstArc[v]; arc < cch.firstArc[v+1]; arc++ {
+			for d := range cch.cost {
+				graph.applyArc(int64(v), arc, d)
+			}
+		}
+	}
+	graph.publishAllWeights()
+}
+
+// applyArc Stores cost of arc (in direction d) in incident edges and shortcuts of graph
+//
+// lower - Library defined ID of lower ranked vertex of arc
+func (graph *Graph) applyArc(lower, arc int64, d int) {
+	cch := graph.cch
+	from, to := lower, cch.head[arc]
+	if d == cchDown {
+		from, to = to, from
+	}
+	cost := cch.cost[d][arc]
+	if cch.outPos[d][arc] < 0 {
+		if cch.via[d][arc] < 0 {
+			// Arc is represented by initial edges (or there is no path between vertices below them in hierarchies)
+			return
+		}
+		cch.outPos[d][arc] = len(graph.Vertices[from].outIncidentEdges)
+		cch.inPos[d][arc] = len(graph.Vertices[to].inIncidentEdges)
+		graph.Vertices[from].addOutShortcutEdge(to, cost)
+		graph.Vertices[to].addInShortcutEdge(from, cost)
+	} else {
+		graph.Vertices[from].outIncidentEdges[cch.outPos[d][arc]].weight = cost
+		graph.Vertices[to].inIncidentEdges[cch.inPos[d][arc]].weight = cost
+	}
+	graph.setCustomizedShortcut(from, to, cch.via[d][arc], cost)
+}
+
+// setCustomizedShortcut Creates, updates or removes (if arc is the initial edge: viaVertex is -1) shortcut
+func (graph *Graph) setCustomizedShortcut(fromVertex, toVertex, viaVertex int64, cost float64) {
+	existing, ok := graph.shortcuts[fromVertex][toVertex]
+	if viaVertex < 0 {
+		if ok {
+			delete(graph.shortcuts[fromVertex], toVertex)
+			graph.removeShortcutByVia(existing)
+			graph.shortcutsNum--
+		}
+		return
+	}
+	if !ok {
+		if _, ok := graph.shortcuts[fromVertex]; !ok {
+			graph.shortcuts[fromVertex] = make(map[int64]*ShortcutPath)
+		}
+		shortcut := &ShortcutPath{
+			From: fromVertex,
+			To:   toVertex,
+			Via:  viaVertex,
+			Cost: cost,
+		}
+		graph.shortcuts[fromVertex][toVertex] = shortcut
+		graph.shortcutsByVia[viaVertex] = append(graph.shortcutsByVia[viaVertex], shortcut)
+		graph.shortcutsNum++
+		return
+	}
+	existing.Cost = cost
+	if existing.Via != viaVertex {
+		graph.removeShortcutByVia(existing)
+		existing.Via = viaVertex
+		graph.shortcutsByVia[viaVertex] = append(graph.shortcutsByVia[viaVertex], existing)
+	}
+}
+
+// removeShortcutByVia Removes shortcut from index of shortcuts by Via-vertex
+func (graph *Graph) removeShortcutByVia(shortcut *ShortcutPath) {
+	bucket := graph.shortcutsByVia[shortcut.Via]
+	for i := range bucket {
+		if bucket[i] == shortcut {
+			bucket[i] = bucket[len(bucket)-1]
+			bucket = bucket[:len(bucket)-1]
+			break
+		}
+	}
+	if len(bucket) == 0 {
+		delete(graph.shortcutsByVia, shortcut.Via)
+		return
+	}
+	graph.shortcutsByVia[shortcut.Via] = bucket
+}
+
+// isOriginalOutEdge Checks if j-th outcoming incident edge of vertex is the initial edge of graph (not a shortcut)
+func (graph *Graph) isOriginalOutEdge(vertexNum int64, j int) bool {
+	return !graph.Vertices[vertexNum].outIncidentEdges[j].shortcut
+}
@@ -0,0 +1,185 @@
+package ch
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNestedDissectionOrder(t *testing.T) {
+	const size = 30
+	g := generateGridGraph(size)
+	order := g.NestedDissectionOrder()
+	assert.Len(t, order, size*size)
+	sorted := append([]int64{}, order...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for i := range sorted {
+		if sorted[i] != int64(i) {
+			t.Errorf("Order should be permutation of vertices, but vertex %d is missing", i)
+			return
+		}
+	}
+
+	// Order should be suitable for usual contraction as well
+	err := g.PrepareWithOrder(order)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, source := range []int64{0, 31, 450, 899} {
+		for target := int64(0); target < size*size; target += 11 {
+			expectedCost, _ := g.VanillaShortestPath(source, target)
+			cost, _ := g.ShortestPath(source, target)
+			if math.Abs(expectedCost-cost) > eps {
+				t.Errorf("Cost of path %d -> %d should be %f, but got %f", source, target, expectedCost, cost)
+				return
+			}
+		}
+	}
+}
+
+func TestPrepareCustomizable(t *testing.T) {
+	const size = 20
+	g := generateGridGraph(size)
+	err := g.PrepareCustomizable()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	checkCustomizable(t, g, size*size)
+	if t.Failed() {
+		return
+	}
+
+	// Arbitrary increases and decreases of weights
+	rnd := rand.New(rand.NewSource(1337))
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 100; i++ {
+			from := int64(rnd.Intn(size * size))
+			vertexNum, _ := g.FindVertex(from)
+			edges := g.Vertices[vertexNum].outIncidentEdges[:g.cch.originalOutDegree[vertexNum]]
+			to := g.Vertices[edges[rnd.Intn(len(edges))].vertexID].Label
+			err = g.UpdateEdgeWeight(from, to, 0.1+rnd.Float64()*20, false)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+		err = g.Recustomize()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		checkCustomizable(t, g, size*size)
+		if t.Failed() {
+			return
+		}
+	}
+}
+
+func TestPrepareCustomizableSynthetic(t *testing.T) {
+	g, err := buildSyntheticGraph(50)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = g.PrepareCustomizable()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	checkCustomizable(t, g, int64(len(g.Vertices)))
+	if t.Failed() {
+		return
+	}
+
+	// Make some edges very cheap, so shortest paths change completely
+	source, _ := g.FindVertex(0)
+	for _, edge := range g.Vertices[source].outIncidentEdges[:10] {
+		err = g.UpdateEdgeWeight(0, g.Vertices[edge.vertexID].Label, 0.001, false)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	err = g.Recustomize()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	checkCustomizable(t, g, int64(len(g.Vertices)))
+}
+
+func TestPrepareCustomizableWithOrderInvalid(t *testing.T) {
+	g := generateGridGraph(3)
+	err := g.PrepareCustomizableWithOrder([]int64{0, 1, 2, 3, 4, 5, 6, 7, 7})
+	assert.Equal(t, ErrInvalidOrder, errors.Cause(err))
+	assert.False(t, g.chPrepared)
+	assert.Nil(t, g.cch)
+
+	assert.Equal(t, ErrEmptyGraph, NewGraph().PrepareCustomizable())
+	assert.NoError(t, g.PrepareCustomizable())
+	assert.Equal(t, ErrCHAlreadyPrepared, g.PrepareCustomizable())
+	assert.Equal(t, ErrCHAlreadyPrepared, g.PrepareCustomizableWithOrder([]int64{0, 1, 2, 3, 4, 5, 6, 7, 8}))
+}
+
+func TestCustomizableNotExportable(t *testing.T) {
+	g := generateGridGraph(3)
+	if !assert.NoError(t, g.PrepareCustomizable()) {
+		return
+	}
+	var edges, vertices, shortcuts bytes.Buffer
+	assert.Equal(t, ErrCustomizableNotExportable, g.ExportBinary(&edges))
+	assert.Equal(t, ErrCustomizableNotExportable, g.ExportToWriters(&edges, &vertices, &shortcuts))
+	assert.Equal(t, ErrCustomizableNotExportable, g.ExportShortcutsToWriter(&shortcuts))
+	assert.Zero(t, edges.Len()+vertices.Len()+shortcuts.Len())
+	// Flat representation for queries could be exported
+	assert.NoError(t, g.ExportQueryGraph(&edges))
+}
+
+// checkCustomizable Compares shortest paths in graph prepared by PrepareCustomizable() with Dijkstra's ones
+func checkCustomizable(t *testing.T, g *Graph, verticesNum int64) {
+	pool := g.NewQueryPool()
+	for source := int64(0); source < verticesNum; source += 37 {
+		for target := int64(0); target < verticesNum; target += 3 {
+			expectedCost, _ := g.VanillaShortestPath(source, target)
+			cost, path := g.ShortestPath(source, target)
+			if math.Abs(expectedCost-cost) > eps {
+				t.Errorf("Cost of path %d -> %d should be %f, but got %f", source, target, expectedCost, cost)
+				return
+			}
+			poolCost, _ := pool.ShortestPath(source, target)
+			if math.Abs(expectedCost-poolCost) > eps {
+				t.Errorf("Cost of path %d -> %d (query pool) should be %f, but got %f", source, target, expectedCost, poolCost)
+				return
+			}
+			// Unpacked path should consist of initial edges
+			pathCost := 0.0
+			for i := 1; i < len(path); i++ {
+				from, _ := g.FindVertex(path[i-1])
+				to, _ := g.FindVertex(path[i])
+				// Graph could contain parallel edges
+				edgeCost := -1.0
+				for _, edge := range g.Vertices[from].outIncidentEdges[:g.cch.originalOutDegree[from]] {
+					if edge.vertexID == to && (edgeCost < 0 || edge.weight < edgeCost) {
+						edgeCost = edge.weight
+					}
+				}
+				if edgeCost < 0 {
+					t.Errorf("Path %d -> %d contains %d -> %d which is not an edge", source, target, path[i-1], path[i])
+					return
+				}
+				pathCost += edgeCost
+			}
+			if math.Abs(pathCost-cost) > eps {
+				t.Errorf("Cost of unpacked path %d -> %d should be %f, but got %f", source, target, cost, pathCost)
+				return
+			}
+		}
+	}
+}
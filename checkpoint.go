@@ -0,0 +1,406 @@
+package ch
+
+import (
+	"bufio"
+	"context"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// checkpointMagic Magic number of checkpoint file: "CHCP"
+	checkpointMagic = uint32(0x50434843)
+	// checkpointVersion Current version of checkpoint file format
+	checkpointVersion = uint16(2)
+	// checkpointVertexContracted Vertex has been contracted
+	checkpointVertexContracted = uint16(1 << 0)
+	// checkpointVertexBidirectedCached Number of bidirected edges of vertex has been cached
+	checkpointVertexBidirectedCached = uint16(1 << 1)
+)
+
+// Checkpoint Snapshot of contraction hierarchies preparation. See WithCheckpoint(...) and ResumePreprocess(...)
+type Checkpoint struct {
+	verticesNum      int64
+	edgesNum         int64
+	vertices         []checkpointVertex
+	shortcuts        []checkpointShortcut
+	contractionOrder []int64
+	// Importance heap as it is (order of elements matters for deterministic result)
+	heap []int64
+}
+
+// checkpointVertex State of single vertex
+type checkpointVertex struct {
+	orderPos         int64
+	delNeighbors     int
+	importance       int
+	bidirectedCount  int
+	level            int
+	contracted       bool
+	bidirectedCached bool
+	inIncidentEdges  []incidentEdge
+	outIncidentEdges []incidentEdge
+}
+
+// checkpointShortcut Shortcut and Via-vertex which it is indexed by (they could differ since Via-vertex could be changed by cheaper path)
+type checkpointShortcut struct {
+	indexVia int64
+	shortcut ShortcutPath
+}
+
+// WithCheckpoint Sets callback which is called with snapshot of preparation every N contracted vertices
+//
+// every - Number of contracted vertices between two checkpoints
+// save - Callback for saving checkpoint (e.g. via checkpoint.ExportToFile(...)). If it returns error then preparation is stopped.
+func WithCheckpoint(every int, save func(checkpoint *Checkpoint) error) PrepareOption {
+	return func(options *prepareOptions) {
+		if every < 1 {
+			every = 1
+		}
+		options.checkpointEvery = every
+		options.checkpoint = save
+	}
+}
+
+// Contracted Returns number of vertices which had been contracted when checkpoint was made
+func (checkpoint *Checkpoint) Contracted() int64 {
+	return int64(len(checkpoint.contractionOrder))
+}
+
+// makeCheckpoint Returns snapshot of current state of preparation
+func (graph *Graph) makeCheckpoint(pqImportance *importanceHeap) *Checkpoint {
+	checkpoint := &Checkpoint{
+		verticesNum:      int64(len(graph.Vertices)),
+		edgesNum:         graph.edgesNum,
+		vertices:         make([]checkpointVertex, len(graph.Vertices)),
+		contractionOrder: make([]int64, len(graph.contractionOrder)),
+		heap:             make([]int64, pqImportance.Len()),
+	}
+	for i := range graph.Vertices {
+		vertex := &graph.Vertices[i]
+		checkpoint.vertices[i] = checkpointVertex{
+			orderPos:         vertex.orderPos,
+			delNeighbors:     vertex.delNeighbors,
+			importance:       vertex.importance,
+			bidirectedCount:  vertex.bidirectedCount,
+			level:            vertex.level,
+			contracted:       vertex.contracted,
+			bidirectedCached: vertex.bidirectedCached,
+			inIncidentEdges:  append([]incidentEdge(nil), vertex.inIncidentEdges...),
+			outIncidentEdges: append([]incidentEdge(nil), vertex.outIncidentEdges...),
+		}
+	}
+	for i := range graph.Vertices {
+		for _, shortcut := range graph.shortcutsByVia[int64(i)] {
+			checkpoint.shortcuts = append(checkpoint.shortcuts, checkpointShortcut{indexVia: int64(i), shortcut: *shortcut})
+		}
+	}
+	copy(checkpoint.contractionOrder, graph.contractionOrder)
+	for i, vertex := range *pqImportance {
+		checkpoint.heap[i] = vertex.vertexNum
+	}
+	return checkpoint
+}
+
+// ResumePreprocess Continues contraction hierarchies preparation from checkpoint. See ResumePreprocessContext(...)
+func (graph *Graph) ResumePreprocess(checkpoint *Checkpoint, opts ...PrepareOption) error {
+	return graph.ResumePreprocessContext(context.Background(), checkpoint, opts...)
+}
+
+// ResumePreprocessContext Continues contraction hierarchies preparation from checkpoint
+//
+// Graph should contain the same vertices and edges (added in the same order) as the graph which checkpoint was made for,
+// and it should not be prepared. If preparation is continued with the same options, resulting hierarchies are the same as if preparation had never been stopped.
+func (graph *Graph) ResumePreprocessContext(ctx context.Context, checkpoint *Checkpoint, opts ...PrepareOption) error {
+	if checkpoint.verticesNum != int64(len(graph.Vertices)) || checkpoint.edgesNum != graph.edgesNum {
+		return errors.Wrapf(ErrCheckpointMismatch, "Checkpoint has %d vertices and %d edges, graph has %d vertices and %d edges", checkpoint.verticesNum, checkpoint.edgesNum, len(graph.Vertices), graph.edgesNum)
+	}
+	if graph.chPrepared {
+		return errors.Wrap(ErrCheckpointMismatch, "Contraction hierarchies have been prepared already")
+	}
+	options := newPrepareOptions(opts...)
+	monitor := newPrepareMonitor(ctx, options, checkpoint.Contracted())
+	if err := monitor.interrupted(); err != nil {
+		return err
+	}
+	pqImportance := graph.restoreCheckpoint(checkpoint)
+	return graph.runPreprocess(pqImportance, options, monitor)
+}
+
+// restoreCheckpoint Restores state of preparation from checkpoint and returns importance heap
+func (graph *Graph) restoreCheckpoint(checkpoint *Checkpoint) *importanceHeap {
+	for i := range graph.Vertices {
+		state := checkpoint.vertices[i]
+		vertex := &graph.Vertices[i]
+		vertex.orderPos = state.orderPos
+		vertex.delNeighbors = state.delNeighbors
+		vertex.importance = state.importance
+		vertex.contracted = state.contracted
+		vertex.inIncidentEdges = append([]incidentEdge(nil), state.inIncidentEdges...)
+		vertex.outIncidentEdges = append([]incidentEdge(nil), state.outIncidentEdges...)
+		vertex.bidirectedCount = state.bidirectedCount
+		vertex.level = state.level
+		vertex.bidirectedCached = state.bidirectedCached
+		vertex.distance = NewDistance()
+	}
+
+	graph.shortcuts = make(map[int64]map[int64]*ShortcutPath)
+	graph.shortcutsByVia = make(map[int64][]*ShortcutPath)
+	for _, stored := range checkpoint.shortcuts {
+		shortcut := stored.shortcut
+		if _, ok := graph.shortcuts[shortcut.From]; !ok {
+			graph.shortcuts[shortcut.From] = make(map[int64]*ShortcutPath)
+		}
+		graph.shortcuts[shortcut.From][shortcut.To] = &shortcut
+		graph.shortcutsByVia[stored.indexVia] = append(graph.shortcutsByVia[stored.indexVia], &shortcut)
+	}
+	graph.shortcutsNum = int64(len(checkpoint.shortcuts))
+
+	graph.contractionOrder = make([]int64, len(checkpoint.contractionOrder), len(graph.Vertices))
+	copy(graph.contractionOrder, checkpoint.contractionOrder)
+
+	// Heap is restored as it is: no need to call heap.Init(...)
+	pqImportance := make(importanceHeap, len(checkpoint.heap))
+	for i, vertexNum := range checkpoint.heap {
+		pqImportance[i] = &graph.Vertices[vertexNum]
+	}
+	graph.Freeze()
+	return &pqImportance
+}
+
+// Export Exports checkpoint to binary format
+//
+// Layout (all numbers are little-endian):
+//
+//	header:
+//		magic - uint32, "CHCP"
+//		version - uint16, version of format
+//		flags - uint16, reserved
+//		vertices_num - uint64, number of vertices
+//		edges_num - uint64, number of initial edges
+//		shortcuts_num - uint64, number of shortcuts
+//		order_num - uint64, number of contracted vertices
+//		heap_num - uint64, number of vertices in importance heap
+//	vertices (vertices_num times):
+//		flags - uint16, bit 0 is set for contracted vertex, bit 1 is set when number of bidirected edges is cached
+//		order_pos - int64, del_neighbors - int64, importance - int64, bidirected_count - int64, level - int64
+//		in_degree - uint32, out_degree - uint32
+//		from - uint32, weight - float64, shortcut - uint8 (in_degree times), incoming incident edges (shortcut is 1 for incident edges of shortcuts)
+//		to - uint32, weight - float64, shortcut - uint8 (out_degree times), outcoming incident edges
+//	shortcuts (shortcuts_num times):
+//		index_via - uint32, from - uint32, to - uint32, via - uint32, cost - float64, original_edges - uint32
+//	contraction order:
+//		vertex - uint32 (order_num times)
+//	importance heap:
+//		vertex - uint32 (heap_num times)
+//	checksum - uint32, CRC-32 (IEEE) of all previous bytes
+func (checkpoint *Checkpoint) Export(w io.Writer) error {
+	if checkpoint.verticesNum > math.MaxUint32 {
+		return errors.Wrapf(ErrBinaryTooManyVertices, "Vertices num: %d", checkpoint.verticesNum)
+	}
+	buffered := bufio.NewWriter(w)
+	checksum := crc32.NewIEEE()
+	bw := newBinaryWriter(io.MultiWriter(buffered, checksum))
+
+	bw.uint32(checkpointMagic)
+	bw.uint16(checkpointVersion)
+	bw.uint16(0)
+	bw.uint64(uint64(checkpoint.verticesNum))
+	bw.uint64(uint64(checkpoint.edgesNum))
+	bw.uint64(uint64(len(checkpoint.shortcuts)))
+	bw.uint64(uint64(len(checkpoint.contractionOrder)))
+	bw.uint64(uint64(len(checkpoint.heap)))
+
+	for _, vertex := range checkpoint.vertices {
+		flags := uint16(0)
+		if vertex.contracted {
+			flags |= checkpointVertexContracted
+		}
+		if vertex.bidirectedCached {
+			flags |= checkpointVertexBidirectedCached
+		}
+		bw.uint16(flags)
+		bw.int64(vertex.orderPos)
+		bw.int64(int64(vertex.delNeighbors))
+		bw.int64(int64(vertex.importance))
+		bw.int64(int64(vertex.bidirectedCount))
+		bw.int64(int64(vertex.level))
+		bw.uint32(uint32(len(vertex.inIncidentEdges)))
+		bw.uint32(uint32(len(vertex.outIncidentEdges)))
+		for _, edge := range vertex.inIncidentEdges {
+			bw.uint32(uint32(edge.vertexID))
+			bw.float64(edge.weight)
+			bw.uint8(boolToUint8(edge.shortcut))
+		}
+		for _, edge := range vertex.outIncidentEdges {
+			bw.uint32(uint32(edge.vertexID))
+			bw.float64(edge.weight)
+			bw.uint8(boolToUint8(edge.shortcut))
+		}
+	}
+	for _, stored := range checkpoint.shortcuts {
+		bw.uint32(uint32(stored.indexVia))
+		bw.uint32(uint32(stored.shortcut.From))
+		bw.uint32(uint32(stored.shortcut.To))
+		bw.uint32(uint32(stored.shortcut.Via))
+		bw.float64(stored.shortcut.Cost)
+		bw.uint32(uint32(stored.shortcut.originalEdges))
+	}
+	for _, vertexNum := range checkpoint.contractionOrder {
+		bw.uint32(uint32(vertexNum))
+	}
+	for _, vertexNum := range checkpoint.heap {
+		bw.uint32(uint32(vertexNum))
+	}
+	if bw.err != nil {
+		return errors.Wrap(bw.err, "Can't write checkpoint data")
+	}
+
+	// Checksum itself is not a part of checksum
+	bw.w = buffered
+	bw.uint32(checksum.Sum32())
+	if bw.err != nil {
+		return errors.Wrap(bw.err, "Can't write checksum")
+	}
+	return buffered.Flush()
+}
+
+// ExportToFile Exports checkpoint to file. File is replaced atomically (via temporary file), so previous checkpoint is not lost if writing fails.
+func (checkpoint *Checkpoint) ExportToFile(fname string) error {
+	tmpName := fname + ".tmp"
+	file, err := os.Create(tmpName)
+	if err != nil {
+		return errors.Wrap(err, "Can't create checkpoint file")
+	}
+	err = checkpoint.Export(file)
+	if err != nil {
+		file.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return os.Rename(tmpName, fname)
+}
+
+// ImportCheckpoint Imports checkpoint prepared by Export(w io.Writer) function
+func ImportCheckpoint(r io.Reader) (*Checkpoint, error) {
+	checksum := crc32.NewIEEE()
+	buffered := bufio.NewReader(r)
+	br := newBinaryReader(io.TeeReader(buffered, checksum))
+
+	magic := br.uint32()
+	if br.err != nil {
+		return nil, errors.Wrap(br.err, "Can't read header")
+	}
+	if magic != checkpointMagic {
+		return nil, ErrBinaryBadMagic
+	}
+	version := br.uint16()
+	if br.err == nil && version != checkpointVersion {
+		return nil, errors.Wrapf(ErrBinaryUnsupportedVersion, "Version: %d", version)
+	}
+	br.uint16()
+	verticesNum := br.uint64()
+	edgesNum := br.uint64()
+	shortcutsNum := br.uint64()
+	orderNum := br.uint64()
+	heapNum := br.uint64()
+	if br.err != nil {
+		return nil, errors.Wrap(br.err, "Can't read header")
+	}
+	if verticesNum > math.MaxUint32 || orderNum > verticesNum || heapNum > verticesNum {
+		return nil, errors.Wrap(ErrBinaryCorrupted, "Bad header")
+	}
+
+	readVertexID := func() int64 {
+		vertexNum := int64(br.uint32())
+		if br.err == nil && vertexNum >= int64(verticesNum) {
+			br.err = errors.Wrapf(ErrBinaryCorrupted, "Unknown vertex %d", vertexNum)
+		}
+		return vertexNum
+	}
+	readEdges := func(edgesNum uint32) []incidentEdge {
+		// Counts are not trusted before checksum is verified, so there is no preallocation
+		edges := make([]incidentEdge, 0)
+		for i := uint32(0); i < edgesNum && br.err == nil; i++ {
+			edges = append(edges, incidentEdge{vertexID: readVertexID(), weight: br.float64(), shortcut: br.uint8() != 0})
+		}
+		return edges
+	}
+
+	checkpoint := &Checkpoint{
+		verticesNum: int64(verticesNum),
+		edgesNum:    int64(edgesNum),
+	}
+	for i := uint64(0); i < verticesNum && br.err == nil; i++ {
+		flags := br.uint16()
+		vertex := checkpointVertex{
+			orderPos:         br.int64(),
+			delNeighbors:     int(br.int64()),
+			importance:       int(br.int64()),
+			bidirectedCount:  int(br.int64()),
+			level:            int(br.int64()),
+			contracted:       flags&checkpointVertexContracted != 0,
+			bidirectedCached: flags&checkpointVertexBidirectedCached != 0,
+		}
+		inDegree := br.uint32()
+		outDegree := br.uint32()
+		vertex.inIncidentEdges = readEdges(inDegree)
+		vertex.outIncidentEdges = readEdges(outDegree)
+		checkpoint.vertices = append(checkpoint.vertices, vertex)
+	}
+	if br.err != nil {
+		return nil, errors.Wrap(br.err, "Can't read vertices")
+	}
+	for i := uint64(0); i < shortcutsNum && br.err == nil; i++ {
+		stored := checkpointShortcut{indexVia: readVertexID()}
+		stored.shortcut.From = readVertexID()
+		stored.shortcut.To = readVertexID()
+		stored.shortcut.Via = readVertexID()
+		stored.shortcut.Cost = br.float64()
+		stored.shortcut.originalEdges = int(br.uint32())
+		checkpoint.shortcuts = append(checkpoint.shortcuts, stored)
+	}
+	if br.err != nil {
+		return nil, errors.Wrap(br.err, "Can't read shortcuts")
+	}
+	for i := uint64(0); i < orderNum && br.err == nil; i++ {
+		checkpoint.contractionOrder = append(checkpoint.contractionOrder, readVertexID())
+	}
+	for i := uint64(0); i < heapNum && br.err == nil; i++ {
+		checkpoint.heap = append(checkpoint.heap, readVertexID())
+	}
+	if br.err != nil {
+		return nil, errors.Wrap(br.err, "Can't read contraction order and importance heap")
+	}
+
+	// Checksum itself is not a part of checksum
+	expectedChecksum := checksum.Sum32()
+	br.r = buffered
+	storedChecksum := br.uint32()
+	if br.err != nil {
+		return nil, errors.Wrap(br.err, "Can't read checksum")
+	}
+	if storedChecksum != expectedChecksum {
+		return nil, ErrBinaryChecksumMismatch
+	}
+	return checkpoint, nil
+}
+
+// ImportCheckpointFromFile Imports checkpoint from file. See ImportCheckpoint(r io.Reader) for details.
+func ImportCheckpointFromFile(fname string) (*Checkpoint, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ImportCheckpoint(file)
+}
@@ -0,0 +1,94 @@
+package ch
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		expected := generateGridGraph(40)
+		checkpoints := [][]byte{}
+		err := expected.PrepareContractionHierarchiesContext(context.Background(), WithWorkers(workers), WithCheckpoint(500, func(checkpoint *Checkpoint) error {
+			var buf bytes.Buffer
+			err := checkpoint.Export(&buf)
+			checkpoints = append(checkpoints, buf.Bytes())
+			return err
+		}))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(checkpoints) < 2 {
+			t.Errorf("Workers: %d. There should be at least 2 checkpoints, but got %d", workers, len(checkpoints))
+			return
+		}
+
+		checkpoint, err := ImportCheckpoint(bytes.NewReader(checkpoints[1]))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		assert.True(t, checkpoint.Contracted() >= 1000)
+
+		resumed := generateGridGraph(40)
+		err = resumed.ResumePreprocess(checkpoint, WithWorkers(workers))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		assert.True(t, resumed.chPrepared)
+		assert.Equal(t, expected.contractionOrder, resumed.contractionOrder)
+		assert.Equal(t, expected.GetShortcutsNum(), resumed.GetShortcutsNum())
+		for i := range expected.Vertices {
+			assert.Equal(t, expected.Vertices[i].orderPos, resumed.Vertices[i].orderPos)
+			assert.Equal(t, expected.Vertices[i].outIncidentEdges, resumed.Vertices[i].outIncidentEdges)
+			assert.Equal(t, expected.Vertices[i].inIncidentEdges, resumed.Vertices[i].inIncidentEdges)
+		}
+		for from, shortcuts := range expected.shortcuts {
+			for to, shortcut := range shortcuts {
+				assert.Equal(t, *shortcut, *resumed.shortcuts[from][to])
+			}
+		}
+		for _, source := range []int64{0, 333, 1599} {
+			for target := int64(0); target < 1600; target += 37 {
+				expectedCost, _ := expected.ShortestPath(source, target)
+				cost, _ := resumed.ShortestPath(source, target)
+				assert.InDelta(t, expectedCost, cost, eps)
+			}
+		}
+	}
+}
+
+func TestCheckpointErrors(t *testing.T) {
+	g := generateGridGraph(40)
+	var data []byte
+	saveErr := errors.New("disk is full")
+	err := g.PrepareContractionHierarchiesContext(context.Background(), WithCheckpoint(1000, func(checkpoint *Checkpoint) error {
+		var buf bytes.Buffer
+		if err := checkpoint.Export(&buf); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+		return saveErr
+	}))
+	assert.Equal(t, saveErr, errors.Cause(err))
+
+	damaged := make([]byte, len(data))
+	copy(damaged, data)
+	damaged[len(damaged)/2] ^= 0xFF
+	_, err = ImportCheckpoint(bytes.NewReader(damaged))
+	assert.Error(t, err)
+
+	checkpoint, err := ImportCheckpoint(bytes.NewReader(data))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = generateGridGraph(20).ResumePreprocess(checkpoint)
+	assert.Equal(t, ErrCheckpointMismatch, errors.Cause(err))
+}
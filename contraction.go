@@ -2,6 +2,7 @@ package ch
 
 import (
 	"container/heap"
+	"context"
 	"fmt"
 	"time"
 )
@@ -12,9 +13,18 @@ var (
 
 // Preprocess Computes contraction hierarchies and returns node ordering
 func (graph *Graph) Preprocess(pqImportance *importanceHeap) {
-	extractionOrder := int64(0)
 	// Preallocate contractionOrder slice
 	graph.contractionOrder = make([]int64, 0, len(graph.Vertices))
+	// Background context is never cancelled, so there is no error to handle
+	options := newPrepareOptions()
+	_ = graph.preprocess(pqImportance, options, newPrepareMonitor(context.Background(), options, 0))
+}
+
+// preprocess Computes contraction hierarchies. Returns error if preparation has been interrupted
+//
+// Contraction continues from current state of graph: vertices which are already in contraction order are considered as contracted.
+func (graph *Graph) preprocess(pqImportance *importanceHeap, options *prepareOptions, monitor *prepareMonitor) error {
+	extractionOrder := int64(len(graph.contractionOrder))
 
 	for pqImportance.Len() != 0 {
 		// Lazy update heuristic:
@@ -22,7 +32,7 @@ func (graph *Graph) Preprocess(pqImportance *importanceHeap) {
 		// Before contracting vertex with currently smallest Importance, recompute its Importance and see if it is still the smallest
 		// If not pick next smallest one, recompute its Importance and see if that is the smallest now; If not, continue in same way ...
 		vertex := heap.Pop(pqImportance).(*Vertex)
-		vertex.computeImportance()
+		graph.updateImportance(vertex, options.ordering)
 		if pqImportance.Len() != 0 && vertex.importance > pqImportance.Peek().importance {
 			pqImportance.Push(vertex)
 			continue
@@ -39,24 +49,36 @@ func (graph *Graph) Preprocess(pqImportance *importanceHeap) {
 			}
 		}
 		extractionOrder++
+		if err := monitor.contracted(graph, pqImportance); err != nil {
+			return err
+		}
 	}
 
 	// Mark CH as prepared
 	graph.chPrepared = true
+	graph.publishSnapshot()
+	return nil
 }
 
-// markNeighbors
+// markNeighbors Tells neighbors of vertex that it has been contracted
 //
-// inEdges Incoming edges from vertex
-// outEdges Outcoming edges from vertex
-func (graph *Graph) markNeighbors(inEdges, outEdges []incidentEdge) {
+// vertex Contracted vertex
+func (graph *Graph) markNeighbors(vertex *Vertex) {
+	inEdges := vertex.inIncidentEdges
+	outEdges := vertex.outIncidentEdges
 	for i := range inEdges {
-		temp := inEdges[i]
-		graph.Vertices[temp.vertexID].delNeighbors++
+		temp := &graph.Vertices[inEdges[i].vertexID]
+		temp.delNeighbors++
+		if temp.level <= vertex.level {
+			temp.level = vertex.level + 1
+		}
 	}
 	for i := range outEdges {
-		temp := outEdges[i]
-		graph.Vertices[temp.vertexID].delNeighbors++
+		temp := &graph.Vertices[outEdges[i].vertexID]
+		temp.delNeighbors++
+		if temp.level <= vertex.level {
+			temp.level = vertex.level + 1
+		}
 	}
 }
 
@@ -65,15 +87,23 @@ func (graph *Graph) markNeighbors(inEdges, outEdges []incidentEdge) {
 // vertex Vertex to be contracted
 func (graph *Graph) contractNode(vertex *Vertex) {
 	// Consider all vertices with edges incoming TO current vertex as U
-	incomingEdges := vertex.inIncidentEdges
-
 	// Consider all vertices with edges incoming FROM current vertex as W
-	outcomingEdges := vertex.outIncidentEdges
 
 	// Exclude vertex for local shortest paths searches
 	vertex.contracted = true
 	// Tell neighbor vertices that current vertex has been contracted
-	graph.markNeighbors(incomingEdges, outcomingEdges)
+	graph.markNeighbors(vertex)
+
+	pmax := graph.maxWitnessCost(vertex)
+
+	// Perform a standard Dijkstra’s shortest path search from 'u' on the subgraph excluding current vertex.
+	graph.processIncidentEdges(vertex, pmax)
+}
+
+// maxWitnessCost Returns cost restriction for witness searches around vertex
+func (graph *Graph) maxWitnessCost(vertex *Vertex) float64 {
+	incomingEdges := vertex.inIncidentEdges
+	outcomingEdges := vertex.outIncidentEdges
 
 	// For every vertex 'w' in W, compute Pw as the cost from 'u' to 'w' through current vertex, which is the sum of the edge weights w(u, vertex) + w(vertex, w).
 	inMax := 0.0
@@ -95,10 +125,7 @@ func (graph *Graph) contractNode(vertex *Vertex) {
 		}
 	}
 	// Then Pmax is the maximum pMax over all 'w' in W.
-	pmax := inMax + outMax
-
-	// Perform a standard Dijkstra’s shortest path search from 'u' on the subgraph excluding current vertex.
-	graph.processIncidentEdges(vertex, pmax)
+	return inMax + outMax
 }
 
 // processIncidentEdges Returns evaluated shorcuts
@@ -167,14 +194,15 @@ func (graph *Graph) createOrUpdateShortcut(fromVertex, toVertex, viaVertex int64
 	if existing, ok := graph.shortcuts[fromVertex][toVertex]; !ok {
 		// Prepare shorcut pointer if there is no From-To-Via combo
 		shortcut := &ShortcutPath{
-			From: fromVertex,
-			To:   toVertex,
-			Via:  viaVertex,
-			Cost: summaryCost,
+			From:          fromVertex,
+			To:            toVertex,
+			Via:           viaVertex,
+			Cost:          summaryCost,
+			originalEdges: graph.arcOriginalEdges(fromVertex, viaVertex) + graph.arcOriginalEdges(viaVertex, toVertex),
 		}
 		graph.shortcuts[fromVertex][toVertex] = shortcut
-		graph.Vertices[fromVertex].addOutIncidentEdge(toVertex, summaryCost)
-		graph.Vertices[toVertex].addInIncidentEdge(fromVertex, summaryCost)
+		graph.Vertices[fromVertex].addOutShortcutEdge(toVertex, summaryCost)
+		graph.Vertices[toVertex].addInShortcutEdge(fromVertex, summaryCost)
 		graph.shortcutsNum++
 
 		// Track shortcut by Via-vertex for recustomization
@@ -184,19 +212,31 @@ func (graph *Graph) createOrUpdateShortcut(fromVertex, toVertex, viaVertex int64
 		if summaryCost < existing.Cost {
 			// If middle vertex is not optimal for shortcut then change cost
 			existing.Cost = summaryCost
-			updatedOutSuccess := graph.Vertices[fromVertex].updateOutIncidentEdge(toVertex, summaryCost)
+			updatedOutSuccess := graph.Vertices[fromVertex].updateOutShortcutEdge(toVertex, summaryCost)
 			if !updatedOutSuccess {
 				panic(fmt.Sprintf("Should not happen [1]. Can't update outcoming incident edge. %d has no common edge with %d", fromVertex, toVertex))
 			}
-			updatedInSuccess := graph.Vertices[toVertex].updateInIncidentEdge(fromVertex, summaryCost)
+			updatedInSuccess := graph.Vertices[toVertex].updateInShortcutEdge(fromVertex, summaryCost)
 			if !updatedInSuccess {
 				panic(fmt.Sprintf("Should not happen [2]. Can't update incoming incident edge. %d has no common edge with %d", toVertex, fromVertex))
 			}
 			// We should check if the middle vertex is still the same
 			// We could just do existing.ViaVertex = viaVertex, but it could be helpful for debugging purposes.
 			if existing.Via != viaVertex {
+				// Keep index of shortcuts by Via-vertex consistent (see recustomizeByVia)
+				graph.removeShortcutByVia(existing)
 				existing.Via = viaVertex
+				graph.shortcutsByVia[viaVertex] = append(graph.shortcutsByVia[viaVertex], existing)
+				existing.originalEdges = graph.arcOriginalEdges(fromVertex, viaVertex) + graph.arcOriginalEdges(viaVertex, toVertex)
 			}
 		}
 	}
 }
+
+// arcOriginalEdges Returns number of initial edges represented by arc between vertices (1 if arc is not a shortcut)
+func (graph *Graph) arcOriginalEdges(fromVertex, toVertex int64) int {
+	if shortcut, ok := graph.shortcuts[fromVertex][toVertex]; ok && shortcut.originalEdges > 0 {
+		return shortcut.originalEdges
+	}
+	return 1
+}
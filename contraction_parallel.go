@@ -0,0 +1,235 @@
+package ch
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// parallelCandidatesPerWorker Number of vertices taken from importance heap per worker on every round of parallel contraction
+	parallelCandidatesPerWorker = 32
+)
+
+// preprocessParallel Computes contraction hierarchies by contracting independent sets of vertices concurrently
+//
+// Every round takes vertices with the lowest importance from the heap and greedily picks independent set of them (no two picked vertices are adjacent).
+// All picked vertices are excluded from graph before witness searches, so searches never pass through vertex which shortcuts are not inserted yet
+// (it could only lead to extra shortcuts, not to missing ones). Shortcuts found by workers are inserted serially at the end of the round.
+//
+// Returns error if preparation has been interrupted. Contraction continues from current state of graph (see preprocess(...)).
+func (graph *Graph) preprocessParallel(pqImportance *importanceHeap, options *prepareOptions, monitor *prepareMonitor) error {
+	workers := options.workers
+	extractionOrder := int64(len(graph.contractionOrder))
+
+	searchers := make([]*witnessSearcher, workers)
+	for i := range searchers {
+		searchers[i] = newWitnessSearcher(len(graph.Vertices))
+	}
+
+	maxCandidates := workers * parallelCandidatesPerWorker
+	candidates := make([]*Vertex, 0, maxCandidates)
+	independentSet := make([]*Vertex, 0, maxCandidates)
+	// Vertex is blocked for current round if it is picked or adjacent to picked one (blocked[v] == round)
+	blocked := make([]int64, len(graph.Vertices))
+	round := int64(0)
+
+	for pqImportance.Len() != 0 {
+		round++
+		candidates = candidates[:0]
+		for pqImportance.Len() != 0 && len(candidates) < maxCandidates {
+			vertex := heap.Pop(pqImportance).(*Vertex)
+			graph.updateImportance(vertex, options.ordering)
+			candidates = append(candidates, vertex)
+		}
+		// Lazy update heuristic (see Preprocess(...)): vertex could be picked only if its updated importance is still not greater than the smallest one in heap
+		threshold := Infinity
+		if pqImportance.Len() != 0 {
+			threshold = float64(pqImportance.Peek().importance)
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].importance < candidates[j].importance
+		})
+
+		independentSet = independentSet[:0]
+		for _, vertex := range candidates {
+			if blocked[vertex.vertexNum] == round || float64(vertex.importance) > threshold {
+				heap.Push(pqImportance, vertex)
+				continue
+			}
+			independentSet = append(independentSet, vertex)
+			blocked[vertex.vertexNum] = round
+			for _, edge := range vertex.inIncidentEdges {
+				blocked[edge.vertexID] = round
+			}
+			for _, edge := range vertex.outIncidentEdges {
+				blocked[edge.vertexID] = round
+			}
+		}
+		if len(independentSet) == 0 {
+			continue
+		}
+
+		for _, vertex := range independentSet {
+			vertex.orderPos = extractionOrder
+			extractionOrder++
+			// Exclude vertex for local shortest paths searches
+			vertex.contracted = true
+			// Tell neighbor vertices that current vertex has been contracted
+			graph.markNeighbors(vertex)
+			// Track contraction order for recustomization
+			graph.contractionOrder = append(graph.contractionOrder, vertex.vertexNum)
+		}
+
+		batches := graph.findShortcutsConcurrently(independentSet, searchers)
+		for i := range batches {
+			graph.insertShortcuts(batches[i])
+		}
+
+		if graph.verbose {
+			if extractionOrder/1000 != (extractionOrder-int64(len(independentSet)))/1000 {
+				fmt.Printf("Contraction Order: %d / %d, Remain vertices in heap: %d. Currect shortcuts num: %d Initial edges num: %d Time: %v\n", extractionOrder, len(graph.Vertices), pqImportance.Len(), graph.shortcutsNum, graph.edgesNum, time.Now().Format(tmLayout))
+			}
+		}
+		if err := monitor.contracted(graph, pqImportance); err != nil {
+			return err
+		}
+	}
+
+	// Mark CH as prepared
+	graph.chPrepared = true
+	graph.publishSnapshot()
+	return nil
+}
+
+// findShortcutsConcurrently Returns shortcuts needed for contraction of every given vertex (i-th batch corresponds to i-th vertex)
+//
+// Graph is not modified, so searchers could safely share it.
+func (graph *Graph) findShortcutsConcurrently(vertices []*Vertex, searchers []*witnessSearcher) [][]ShortcutPath {
+	batches := make([][]ShortcutPath, len(vertices))
+	workers := len(searchers)
+	if workers > len(vertices) {
+		workers = len(vertices)
+	}
+	if workers == 1 {
+		for i := range vertices {
+			batches[i] = searchers[0].findShortcuts(graph, vertices[i])
+		}
+		return batches
+	}
+	next := int64(-1)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func(searcher *witnessSearcher) {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= len(vertices) {
+					return
+				}
+				batches[i] = searcher.findShortcuts(graph, vertices[i])
+			}
+		}(searchers[w])
+	}
+	wg.Wait()
+	return batches
+}
+
+// witnessSearcher Local Dijkstra's search which keeps distances in its own buffers (instead of Vertex.distance),
+// so multiple searchers could be run on the same graph concurrently
+type witnessSearcher struct {
+	dist []float64
+	// Epoch markers (if != epoch, distance is Infinity)
+	epochs []int64
+	epoch  int64
+	queue  vertexDistHeap
+}
+
+func newWitnessSearcher(verticesNum int) *witnessSearcher {
+	return &witnessSearcher{
+		dist:   make([]float64, verticesNum),
+		epochs: make([]int64, verticesNum),
+	}
+}
+
+// distanceTo Returns distance found by last search (Infinity if vertex has not been reached)
+func (searcher *witnessSearcher) distanceTo(vertexID int64) float64 {
+	if searcher.epochs[vertexID] != searcher.epoch {
+		return Infinity
+	}
+	return searcher.dist[vertexID]
+}
+
+// shortestPathsWithMaxCost Computes shortest distances from source to other vertices. See Graph.shortestPathsWithMaxCost(...)
+func (searcher *witnessSearcher) shortestPathsWithMaxCost(graph *Graph, source int64, maxcost float64) {
+	searcher.epoch++
+	searcher.queue = searcher.queue[:0]
+	searcher.dist[source] = 0
+	searcher.epochs[source] = searcher.epoch
+	heap.Push(&searcher.queue, &vertexDist{id: source, dist: 0})
+
+	for searcher.queue.Len() != 0 {
+		vertex := heap.Pop(&searcher.queue).(*vertexDist)
+		// Skip outdated heap entries
+		if vertex.dist > searcher.dist[vertex.id] {
+			continue
+		}
+		// Once a vertex is settled with a shortest path score greater than max cost, search stops.
+		if vertex.dist > maxcost {
+			return
+		}
+		// Edge relaxation
+		vertexList := graph.Vertices[vertex.id].outIncidentEdges
+		for i := range vertexList {
+			temp := vertexList[i].vertexID
+			// Do not consider any vertex has been excluded earlier
+			if graph.Vertices[temp].contracted {
+				continue
+			}
+			alt := vertex.dist + vertexList[i].weight
+			if searcher.epochs[temp] != searcher.epoch || searcher.dist[temp] > alt {
+				searcher.dist[temp] = alt
+				searcher.epochs[temp] = searcher.epoch
+				heap.Push(&searcher.queue, &vertexDist{id: temp, dist: alt})
+			}
+		}
+	}
+}
+
+// findShortcuts Returns shortcuts needed for contraction of vertex. See Graph.processIncidentEdges(...)
+//
+// vertex must be already excluded from graph (marked as contracted)
+func (searcher *witnessSearcher) findShortcuts(graph *Graph, vertex *Vertex) []ShortcutPath {
+	incomingEdges := vertex.inIncidentEdges
+	outcomingEdges := vertex.outIncidentEdges
+	if len(outcomingEdges) == 0 {
+		return nil
+	}
+	pmax := graph.maxWitnessCost(vertex)
+	batchShortcuts := make([]ShortcutPath, 0)
+	for _, u := range incomingEdges {
+		inVertex := u.vertexID
+		// Do not consider any vertex has been excluded earlier
+		if graph.Vertices[inVertex].contracted {
+			continue
+		}
+		searcher.shortestPathsWithMaxCost(graph, inVertex, pmax)
+		for _, w := range outcomingEdges {
+			outVertex := w.vertexID
+			// Do not consider any vertex has been excluded earlier
+			if graph.Vertices[outVertex].contracted {
+				continue
+			}
+			neighborsWeights := u.weight + w.weight
+			// For each w, if dist(u, w) > Pw we add a shortcut edge uw with weight Pw.
+			if searcher.distanceTo(outVertex) > neighborsWeights {
+				batchShortcuts = append(batchShortcuts, ShortcutPath{From: inVertex, To: outVertex, Via: vertex.vertexNum, Cost: neighborsWeights})
+			}
+		}
+	}
+	return batchShortcuts
+}
@@ -0,0 +1,75 @@
+package ch
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParallelContraction(t *testing.T) {
+	for _, workers := range []int{2, 4} {
+		g, err := buildSyntheticGraph(40)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		g.PrepareContractionHierarchies(WithWorkers(workers))
+		if len(g.contractionOrder) != len(g.Vertices) {
+			t.Errorf("Contraction order should contain %d vertices, but got %d", len(g.Vertices), len(g.contractionOrder))
+			return
+		}
+		for i := range g.Vertices {
+			for j := range g.Vertices {
+				source, target := g.Vertices[i].Label, g.Vertices[j].Label
+				expectedCost, _ := g.VanillaShortestPath(source, target)
+				cost, path := g.ShortestPath(source, target)
+				if math.Abs(expectedCost-cost) > eps {
+					t.Errorf("Workers: %d. Cost of path %d -> %d should be %f, but got %f", workers, source, target, expectedCost, cost)
+					return
+				}
+				if cost >= 0 && (path[0] != source || path[len(path)-1] != target) {
+					t.Errorf("Workers: %d. Path %d -> %d has wrong endpoints: %v", workers, source, target, path)
+					return
+				}
+			}
+		}
+	}
+}
+
+func TestParallelContractionGrid(t *testing.T) {
+	const size = 30
+	g := generateGridGraph(size)
+	g.PrepareContractionHierarchies(WithWorkers(4))
+	for _, source := range []int64{0, 17, 450, 899} {
+		for target := int64(0); target < size*size; target += 13 {
+			expectedCost, _ := g.VanillaShortestPath(source, target)
+			cost, _ := g.ShortestPath(source, target)
+			if math.Abs(expectedCost-cost) > eps {
+				t.Errorf("Cost of path %d -> %d should be %f, but got %f", source, target, expectedCost, cost)
+				return
+			}
+		}
+	}
+}
+
+// generateGridGraph Returns size*size grid graph (without contraction hierarchies) with pseudo-random asymmetric weights
+func generateGridGraph(size int) *Graph {
+	g := NewGraph()
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			vertex := int64(y*size + x)
+			if x+1 < size {
+				g.CreateVertex(vertex)
+				g.CreateVertex(vertex + 1)
+				g.AddEdge(vertex, vertex+1, float64(1+(x*7+y*3)%5))
+				g.AddEdge(vertex+1, vertex, float64(1+(x*3+y*7)%5))
+			}
+			if y+1 < size {
+				g.CreateVertex(vertex)
+				g.CreateVertex(vertex + int64(size))
+				g.AddEdge(vertex, vertex+int64(size), float64(1+(x*5+y)%4))
+				g.AddEdge(vertex+int64(size), vertex, float64(1+(x+y*5)%4))
+			}
+		}
+	}
+	return g
+}
@@ -171,6 +171,37 @@ func (graph *Graph) shortestPathWithAlternatives(endpoints [directionsCount][]ve
 
 // ComputePath Returns slice of IDs (user defined) of computed path
 func (graph *Graph) ComputePath(middleID int64, forwardPrev, backwardPrev map[int64]int64) []int64 {
+	path := joinPathParts(middleID, forwardPrev, backwardPrev)
+
+	// Expand shortcuts iteratively
+	for {
+		expanded := false
+		newPath := make([]int64, 0, len(path)*2)
+		for i := 0; i < len(path); i++ {
+			newPath = append(newPath, path[i])
+			if i+1 < len(path) {
+				if shortcut, ok := graph.shortcuts[path[i]][path[i+1]]; ok {
+					newPath = append(newPath, shortcut.Via)
+					expanded = true
+				}
+			}
+		}
+		path = newPath
+		if !expanded {
+			break
+		}
+	}
+
+	// Convert internal IDs to user labels
+	for i := range path {
+		path[i] = graph.Vertices[path[i]].Label
+	}
+
+	return path
+}
+
+// joinPathParts Returns path (library defined IDs, shortcuts are not expanded) which is combined from forward and backward search trees meeting in middle vertex
+func joinPathParts(middleID int64, forwardPrev, backwardPrev map[int64]int64) []int64 {
 	// Build forward path (reversed, from middle to source)
 	forwardPath := make([]int64, 0, 16)
 	u := middleID
@@ -205,30 +236,5 @@ func (graph *Graph) ComputePath(middleID int64, forwardPrev, backwardPrev map[in
 	}
 	path = append(path, middleID)
 	path = append(path, backwardPath...)
-
-	// Expand shortcuts iteratively
-	for {
-		expanded := false
-		newPath := make([]int64, 0, len(path)*2)
-		for i := 0; i < len(path); i++ {
-			newPath = append(newPath, path[i])
-			if i+1 < len(path) {
-				if shortcut, ok := graph.shortcuts[path[i]][path[i+1]]; ok {
-					newPath = append(newPath, shortcut.Via)
-					expanded = true
-				}
-			}
-		}
-		path = newPath
-		if !expanded {
-			break
-		}
-	}
-
-	// Convert internal IDs to user labels
-	for i := range path {
-		path[i] = graph.Vertices[path[i]].Label
-	}
-
 	return path
 }
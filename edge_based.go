@@ -0,0 +1,318 @@
+package ch
+
+import (
+	"github.com/pkg/errors"
+)
+
+// TurnCostFunc Returns cost of turn from -> via -> to (user's defined IDs of vertices). Turn is forbidden if cost is Infinity
+type TurnCostFunc func(from, via, to int64) float64
+
+// EdgeBasedGraph Contraction hierarchies of line graph (edge-based graph) with turn restrictions and turn costs. See Graph.PrepareEdgeBased(...)
+//
+// Every vertex of line graph (state) is an edge of original graph reached after some sequence of edges:
+// plain state of edge u -> v is used when no turn restriction is in progress, and extra copies of edges are created for every proper prefix of multi-via turn restrictions.
+// Arc between two states is a turn: its cost is the turn cost plus weight of the second edge. Forbidden turns are just not added.
+type EdgeBasedGraph struct {
+	// Contraction hierarchies of line graph: label of vertex is the index of state
+	lineGraph *Graph
+	// User's defined IDs of vertices of original graph (indexed by library defined ID) and mapping back
+	labels  []int64
+	mapping map[int64]int64
+	// Edge of every state (library defined IDs of vertices of original graph)
+	tail []int64
+	head []int64
+	// Plain states of edges which start at vertex (with weights of edges) and all states of edges which end at vertex
+	sources [][]VertexAlternative
+	targets [][]VertexAlternative
+}
+
+// AddMultiViaTurnRestriction Adds turn restriction which forbids to pass given sequence of vertices: from -> via[0] -> ... -> via[len(via)-1] -> to.
+// Any number of restrictions could share the same vertices. Restrictions are honored by edge-based contraction hierarchies (see PrepareEdgeBased(...)).
+//
+// from - User's definied ID of source vertex
+// via - User's definied IDs of vertices between source and target (at least one)
+// to - User's definied ID of target vertex
+//
+// Returns error if graph is frozen, if via is empty or if some of vertices or edges between consecutive vertices is not found.
+func (graph *Graph) AddMultiViaTurnRestriction(from int64, via []int64, to int64) error {
+	if graph.frozen {
+		return ErrGraphIsFrozen
+	}
+	if len(via) == 0 {
+		return errors.Wrap(ErrInvalidTurnRestriction, "No via vertices")
+	}
+	labels := make([]int64, 0, len(via)+2)
+	labels = append(labels, from)
+	labels = append(labels, via...)
+	labels = append(labels, to)
+	vertices := make([]int64, 0, len(labels))
+	for i := 1; i < len(labels); i++ {
+		edge, err := graph.internalEdge(EdgeKey{From: labels[i-1], To: labels[i]})
+		if err != nil {
+			return err
+		}
+		if i == 1 {
+			vertices = append(vertices, edge.From)
+		}
+		vertices = append(vertices, edge.To)
+	}
+	graph.turnRestrictions = append(graph.turnRestrictions, vertices)
+	return nil
+}
+
+// PrepareEdgeBased Computes contraction hierarchies of line graph (edge-based graph), so turn restrictions and turn costs are the part of contracted graph.
+// Original graph is not modified: only its initial edges (not shortcuts) are used.
+//
+// turnCost - Cost of every turn (could be nil: turns are free then). It is added to costs from tables of vertices (see AddTurnCost(...))
+// opts - Options of contraction of line graph (see PrepareContractionHierarchies(...))
+//
+// Every restriction added by AddTurnRestriction(...) or AddMultiViaTurnRestriction(...) is honored, multi-via restrictions as well:
+// states of line graph are nodes of Aho-Corasick automaton over sequences of restricted vertices, so path never passes through any of forbidden sequences.
+func (graph *Graph) PrepareEdgeBased(turnCost TurnCostFunc, opts ...PrepareOption) *EdgeBasedGraph {
+	n := len(graph.Vertices)
+	automaton := graph.buildTurnAutomaton()
+	weights := automaton.weights
+
+	ebg := &EdgeBasedGraph{
+		lineGraph: NewGraph(),
+		labels:    make([]int64, n),
+		mapping:   graph.mapping,
+		tail:      automaton.tail,
+		head:      automaton.head,
+		sources:   make([][]VertexAlternative, n),
+		targets:   make([][]VertexAlternative, n),
+	}
+	for v := range graph.Vertices {
+		ebg.labels[v] = graph.Vertices[v].Label
+	}
+	for state := range automaton.tail {
+		if automaton.banned[state] {
+			continue
+		}
+		ebg.lineGraph.CreateVertex(int64(state))
+		v := automaton.head[state]
+		ebg.targets[v] = append(ebg.targets[v], VertexAlternative{Label: int64(state)})
+		if automaton.depth[state] == 1 {
+			u := automaton.tail[state]
+			ebg.sources[u] = append(ebg.sources[u], VertexAlternative{Label: int64(state), AdditionalDistance: weights[u][v]})
+		}
+	}
+	for state := range automaton.tail {
+		if automaton.banned[state] {
+			continue
+		}
+		u, v := automaton.tail[state], automaton.head[state]
+		for _, x := range automaton.neighbors[v] {
+			next := automaton.next(int64(state), x)
+			if automaton.banned[next] {
+				continue
+			}
+			cost := weights[v][x] + graph.turnCost(u, v, x, turnCost)
+			if cost >= Infinity {
+				continue
+			}
+			ebg.lineGraph.AddEdge(int64(state), next, cost)
+		}
+	}
+	ebg.lineGraph.PrepareContractionHierarchies(opts...)
+	return ebg
+}
+
+// ShortestPath Computes and returns shortest path and it's cost which honors turn restrictions and turn costs
+//
+// If there are some errors then function returns '-1.0' as cost and nil as shortest path
+//
+// source - User's definied ID of source vertex
+// target - User's definied ID of target vertex
+func (ebg *EdgeBasedGraph) ShortestPath(source, target int64) (float64, []int64) {
+	if source == target {
+		return 0, []int64{source}
+	}
+	sourceInternal, ok := ebg.mapping[source]
+	if !ok {
+		return -1.0, nil
+	}
+	targetInternal, ok := ebg.mapping[target]
+	if !ok {
+		return -1.0, nil
+	}
+	if len(ebg.sources[sourceInternal]) == 0 || len(ebg.targets[targetInternal]) == 0 {
+		return -1.0, nil
+	}
+	cost, states := ebg.lineGraph.ShortestPathWithAlternatives(ebg.sources[sourceInternal], ebg.targets[targetInternal])
+	if cost < 0 {
+		return -1.0, nil
+	}
+	path := make([]int64, 0, len(states)+1)
+	path = append(path, ebg.labels[ebg.tail[states[0]]])
+	for _, state := range states {
+		path = append(path, ebg.labels[ebg.head[state]])
+	}
+	return cost, path
+}
+
+// GetStatesNum Returns number of vertices of line graph: edges of original graph and their copies for multi-via turn restrictions
+func (ebg *EdgeBasedGraph) GetStatesNum() int64 {
+	return int64(len(ebg.lineGraph.Vertices))
+}
+
+// originalOutIncidentEdges Returns outcoming incident edges of vertex which are initial edges (not shortcuts)
+func (graph *Graph) originalOutIncidentEdges(vertexNum int64) []incidentEdge {
+	return originalIncidentEdges(graph.Vertices[vertexNum].outIncidentEdges)
+}
+
+// originalInIncidentEdges Returns incoming incident edges of vertex which are initial edges (not shortcuts)
+func (graph *Graph) originalInIncidentEdges(vertexNum int64) []incidentEdge {
+	return originalIncidentEdges(graph.Vertices[vertexNum].inIncidentEdges)
+}
+
+// originalIncidentEdges Returns incident edges which are not marked as shortcuts. Given slice is returned as is if there are no shortcuts
+func originalIncidentEdges(edges []incidentEdge) []incidentEdge {
+	for i := range edges {
+		if !edges[i].shortcut {
+			continue
+		}
+		original := make([]incidentEdge, i, len(edges))
+		copy(original, edges[:i])
+		for _, edge := range edges[i+1:] {
+			if !edge.shortcut {
+				original = append(original, edge)
+			}
+		}
+		return original
+	}
+	return edges
+}
+
+// turnAutomaton Aho-Corasick automaton over sequences of vertices forbidden by turn restrictions
+//
+// Every state is an edge tail -> head reached after sequence of vertices: states of depth 1 are plain edges,
+// deeper states are proper prefixes of restrictions (banned states are whole restrictions or contain some of them as suffix).
+type turnAutomaton struct {
+	// Distinct neighbors of every vertex and the cheapest weight of parallel edges
+	neighbors [][]int64
+	weights   []map[int64]float64
+
+	tail     []int64
+	head     []int64
+	depth    []int
+	banned   []bool
+	fail     []int64
+	children []map[int64]int64
+	// Plain states of edges by tail and head
+	plain []map[int64]int64
+}
+
+// buildTurnAutomaton Builds automaton for initial edges (not shortcuts) and turn restrictions of graph
+func (graph *Graph) buildTurnAutomaton() *turnAutomaton {
+	n := len(graph.Vertices)
+	neighbors := make([][]int64, n)
+	weights := make([]map[int64]float64, n)
+	for v := range graph.Vertices {
+		weights[v] = make(map[int64]float64)
+		for _, edge := range graph.originalOutIncidentEdges(int64(v)) {
+			weight, ok := weights[v][edge.vertexID]
+			if !ok {
+				neighbors[v] = append(neighbors[v], edge.vertexID)
+			}
+			if !ok || edge.weight < weight {
+				weights[v][edge.vertexID] = edge.weight
+			}
+		}
+	}
+	automaton := newTurnAutomaton(neighbors, weights)
+	for _, restriction := range graph.turnRestrictions {
+		automaton.addRestriction(restriction)
+	}
+	automaton.buildFailureLinks()
+	return automaton
+}
+
+func newTurnAutomaton(neighbors [][]int64, weights []map[int64]float64) *turnAutomaton {
+	automaton := &turnAutomaton{
+		neighbors: neighbors,
+		weights:   weights,
+		plain:     make([]map[int64]int64, len(neighbors)),
+	}
+	for u := range neighbors {
+		automaton.plain[u] = make(map[int64]int64, len(neighbors[u]))
+		for _, v := range neighbors[u] {
+			automaton.plain[u][v] = automaton.addState(int64(u), v, 1)
+		}
+	}
+	return automaton
+}
+
+// addState Adds state for edge tail -> head and returns its index
+func (automaton *turnAutomaton) addState(tail, head int64, depth int) int64 {
+	automaton.tail = append(automaton.tail, tail)
+	automaton.head = append(automaton.head, head)
+	automaton.depth = append(automaton.depth, depth)
+	automaton.banned = append(automaton.banned, false)
+	automaton.fail = append(automaton.fail, -1)
+	automaton.children = append(automaton.children, nil)
+	return int64(len(automaton.tail) - 1)
+}
+
+// addRestriction Adds sequence of vertices (library defined IDs) to automaton. Restrictions with missing edges are ignored.
+func (automaton *turnAutomaton) addRestriction(vertices []int64) {
+	if len(vertices) < 3 {
+		return
+	}
+	for i := 1; i < len(vertices); i++ {
+		if _, ok := automaton.weights[vertices[i-1]][vertices[i]]; !ok {
+			return
+		}
+	}
+	state := automaton.plain[vertices[0]][vertices[1]]
+	for i := 2; i < len(vertices); i++ {
+		child, ok := automaton.children[state][vertices[i]]
+		if !ok {
+			child = automaton.addState(vertices[i-1], vertices[i], automaton.depth[state]+1)
+			if automaton.children[state] == nil {
+				automaton.children[state] = make(map[int64]int64)
+			}
+			automaton.children[state][vertices[i]] = child
+		}
+		state = child
+	}
+	automaton.banned[state] = true
+}
+
+// buildFailureLinks Computes failure links (the longest proper suffix of state which is a state too) in order of depth
+func (automaton *turnAutomaton) buildFailureLinks() {
+	queue := make([]int64, 0)
+	for state := range automaton.tail {
+		if automaton.depth[state] == 1 {
+			queue = append(queue, int64(state))
+		}
+	}
+	for len(queue) != 0 {
+		state := queue[0]
+		queue = queue[1:]
+		for x, child := range automaton.children[state] {
+			if automaton.depth[state] == 1 {
+				automaton.fail[child] = automaton.plain[automaton.head[state]][x]
+			} else {
+				automaton.fail[child] = automaton.next(automaton.fail[state], x)
+			}
+			if automaton.banned[automaton.fail[child]] {
+				automaton.banned[child] = true
+			}
+			queue = append(queue, child)
+		}
+	}
+}
+
+// next Returns state which is reached from given one by edge from its head to vertex x
+func (automaton *turnAutomaton) next(state, x int64) int64 {
+	for {
+		if child, ok := automaton.children[state][x]; ok {
+			return child
+		}
+		if automaton.depth[state] == 1 {
+			return automaton.plain[automaton.head[state]][x]
+		}
+		state = automaton.fail[state]
+	}
+}
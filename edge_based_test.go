@@ -0,0 +1,295 @@
+package ch
+
+import (
+	"bytes"
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEdgeBasedWithoutRestrictions(t *testing.T) {
+	const size = 10
+	g := generateGridGraph(size)
+	ebg := g.PrepareEdgeBased(nil)
+	for source := int64(0); source < size*size; source += 7 {
+		for target := int64(0); target < size*size; target += 3 {
+			expectedCost, _ := g.VanillaShortestPath(source, target)
+			cost, path := ebg.ShortestPath(source, target)
+			if math.Abs(expectedCost-cost) > eps {
+				t.Errorf("Cost of path %d -> %d should be %f, but got %f", source, target, expectedCost, cost)
+				return
+			}
+			assert.Equal(t, source, path[0])
+			assert.Equal(t, target, path[len(path)-1])
+		}
+	}
+}
+
+func TestEdgeBasedRestrictionsSameVia(t *testing.T) {
+	// 3x3 grid with unit weights: straight path 1 -> 4 -> 7 and turns 3 -> 4 -> 7, 5 -> 4 -> 7 are forbidden
+	g := NewGraph()
+	for i := int64(0); i < 9; i++ {
+		g.CreateVertex(i)
+	}
+	for i := int64(0); i < 9; i++ {
+		if i%3 != 2 {
+			g.AddEdge(i, i+1, 1)
+			g.AddEdge(i+1, i, 1)
+		}
+		if i < 6 {
+			g.AddEdge(i, i+3, 1)
+			g.AddEdge(i+3, i, 1)
+		}
+	}
+	g.AddTurnRestriction(1, 4, 7)
+	g.AddTurnRestriction(3, 4, 7)
+	g.AddTurnRestriction(5, 4, 7)
+	ebg := g.PrepareEdgeBased(nil)
+
+	cost, path := ebg.ShortestPath(1, 7)
+	assert.Equal(t, 4.0, cost)
+	assert.Len(t, path, 5)
+	for i := 2; i < len(path); i++ {
+		assert.False(t, path[i-1] == 4 && path[i] == 7, "Path %v enters 7 from 4", path)
+	}
+	// Restrictions don't affect path which starts at via vertex
+	cost, path = ebg.ShortestPath(4, 7)
+	assert.Equal(t, 1.0, cost)
+	assert.Equal(t, []int64{4, 7}, path)
+}
+
+func TestEdgeBasedMultiVia(t *testing.T) {
+	// Chain 0 -> 1 -> 2 -> 3 and detour 1 -> 4 -> 2. Passing 0 -> 1 -> 2 -> 3 is forbidden
+	g := NewGraph()
+	for i := int64(0); i < 5; i++ {
+		g.CreateVertex(i)
+	}
+	g.AddEdge(0, 1, 1)
+	g.AddEdge(1, 2, 1)
+	g.AddEdge(2, 3, 1)
+	g.AddEdge(1, 4, 1.5)
+	g.AddEdge(4, 2, 1.5)
+	err := g.AddMultiViaTurnRestriction(0, []int64{1, 2}, 3)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	ebg := g.PrepareEdgeBased(nil)
+	// Plain edges and one copy of edge 1 -> 2 entered from 0
+	assert.Equal(t, int64(6), ebg.GetStatesNum())
+
+	cost, path := ebg.ShortestPath(0, 3)
+	assert.Equal(t, 5.0, cost)
+	assert.Equal(t, []int64{0, 1, 4, 2, 3}, path)
+	cost, path = ebg.ShortestPath(0, 2)
+	assert.Equal(t, 2.0, cost)
+	assert.Equal(t, []int64{0, 1, 2}, path)
+	cost, path = ebg.ShortestPath(1, 3)
+	assert.Equal(t, 2.0, cost)
+	assert.Equal(t, []int64{1, 2, 3}, path)
+	cost, path = ebg.ShortestPath(3, 0)
+	assert.Equal(t, -1.0, cost)
+	assert.Nil(t, path)
+
+	err = g.AddMultiViaTurnRestriction(0, nil, 1)
+	assert.Equal(t, ErrInvalidTurnRestriction, errors.Cause(err))
+	err = g.AddMultiViaTurnRestriction(0, []int64{2}, 3)
+	assert.Equal(t, ErrEdgeNotFound, errors.Cause(err))
+	err = g.AddMultiViaTurnRestriction(0, []int64{100}, 3)
+	assert.Equal(t, ErrVertexNotFound, errors.Cause(err))
+}
+
+func TestEdgeBasedTurnCosts(t *testing.T) {
+	// U-turns are forbidden, turn to 2 at vertex 1 costs 10
+	g := NewGraph()
+	for i := int64(0); i < 4; i++ {
+		g.CreateVertex(i)
+	}
+	g.AddEdge(0, 1, 1)
+	g.AddEdge(1, 0, 1)
+	g.AddEdge(1, 2, 1)
+	g.AddEdge(1, 3, 2)
+	g.AddEdge(3, 2, 2)
+	ebg := g.PrepareEdgeBased(func(from, via, to int64) float64 {
+		if from == to {
+			return Infinity
+		}
+		if via == 1 && to == 2 {
+			return 10
+		}
+		return 0
+	})
+	cost, path := ebg.ShortestPath(0, 2)
+	assert.Equal(t, 5.0, cost)
+	assert.Equal(t, []int64{0, 1, 3, 2}, path)
+	cost, _ = ebg.ShortestPath(1, 2)
+	assert.Equal(t, 1.0, cost)
+	cost, _ = ebg.ShortestPath(0, 0)
+	assert.Equal(t, 0.0, cost)
+	cost, _ = ebg.ShortestPath(1, 0)
+	assert.Equal(t, 1.0, cost)
+}
+
+func TestEdgeBasedRandomRestrictions(t *testing.T) {
+	const size = 6
+	g := generateGridGraph(size)
+	rnd := rand.New(rand.NewSource(42))
+	restrictions := make([][]int64, 0)
+	for len(restrictions) < 40 {
+		// Random walk of 3 or 4 vertices
+		walk := []int64{int64(rnd.Intn(size * size))}
+		for len(walk) < 3+rnd.Intn(2) {
+			edges := g.Vertices[g.mapping[walk[len(walk)-1]]].outIncidentEdges
+			walk = append(walk, g.Vertices[edges[rnd.Intn(len(edges))].vertexID].Label)
+		}
+		assert.NoError(t, g.AddMultiViaTurnRestriction(walk[0], walk[1:len(walk)-1], walk[len(walk)-1]))
+		restrictions = append(restrictions, walk)
+	}
+	turnCost := func(from, via, to int64) float64 {
+		if from == to {
+			return 3
+		}
+		return float64((from + via + to) % 2)
+	}
+	ebg := g.PrepareEdgeBased(turnCost)
+	for source := int64(0); source < size*size; source += 5 {
+		for target := int64(0); target < size*size; target += 2 {
+			expectedCost := bruteForceTurnPath(g, restrictions, turnCost, source, target)
+			cost, path := ebg.ShortestPath(source, target)
+			if math.Abs(expectedCost-cost) > eps {
+				t.Errorf("Cost of path %d -> %d should be %f, but got %f", source, target, expectedCost, cost)
+				return
+			}
+			if cost < 0 {
+				continue
+			}
+			// Path should not contain restricted sequences and its cost should match
+			pathCost := 0.0
+			for i := 1; i < len(path); i++ {
+				from, _ := g.FindVertex(path[i-1])
+				to, _ := g.FindVertex(path[i])
+				pathCost += g.getEdgeCost(from, to)
+				if i > 1 {
+					pathCost += turnCost(path[i-2], path[i-1], path[i])
+				}
+			}
+			assert.InDelta(t, cost, pathCost, eps)
+			for _, restriction := range restrictions {
+				for i := len(restriction); i <= len(path); i++ {
+					if fmt.Sprint(path[i-len(restriction):i]) == fmt.Sprint(restriction) {
+						t.Errorf("Path %v contains restricted sequence %v", path, restriction)
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// bruteForceTurnPath Dijkstra's algorithm over states "last vertices of path" (enough to check every restriction)
+func bruteForceTurnPath(g *Graph, restrictions [][]int64, turnCost TurnCostFunc, source, target int64) float64 {
+	if source == target {
+		return 0
+	}
+	maxLen := 0
+	for _, restriction := range restrictions {
+		if len(restriction) > maxLen {
+			maxLen = len(restriction)
+		}
+	}
+	dist := map[string]float64{fmt.Sprint([]int64{source}): 0}
+	states := map[string][]int64{fmt.Sprint([]int64{source}): {source}}
+	queue := &minHeap{}
+	ids := map[string]int64{}
+	keys := []string{}
+	id := func(key string) int64 {
+		if i, ok := ids[key]; ok {
+			return i
+		}
+		ids[key] = int64(len(keys))
+		keys = append(keys, key)
+		return ids[key]
+	}
+	queue.add_with_priority(id(fmt.Sprint([]int64{source})), 0)
+	for queue.Len() != 0 {
+		item := heap.Pop(queue).(*minHeapVertex)
+		key := keys[item.id]
+		if item.distance > dist[key] {
+			continue
+		}
+		tail := states[key]
+		last := tail[len(tail)-1]
+		if last == target {
+			return item.distance
+		}
+		lastInternal := g.mapping[last]
+		for _, edge := range g.Vertices[lastInternal].outIncidentEdges {
+			next := g.Vertices[edge.vertexID].Label
+			extended := append(append([]int64{}, tail...), next)
+			banned := false
+			for _, restriction := range restrictions {
+				if len(extended) >= len(restriction) && fmt.Sprint(extended[len(extended)-len(restriction):]) == fmt.Sprint(restriction) {
+					banned = true
+				}
+			}
+			if banned {
+				continue
+			}
+			cost := item.distance + edge.weight
+			if len(tail) > 1 {
+				cost += turnCost(tail[len(tail)-2], last, next)
+			}
+			if len(extended) > maxLen-1 {
+				extended = extended[len(extended)-(maxLen-1):]
+			}
+			nextKey := fmt.Sprint(extended)
+			if d, ok := dist[nextKey]; !ok || cost < d {
+				dist[nextKey] = cost
+				states[nextKey] = extended
+				queue.add_with_priority(id(nextKey), cost)
+			}
+		}
+	}
+	return -1
+}
+
+func TestOriginalIncidentEdgesParallelShortcut(t *testing.T) {
+	g := parallelShortcutGraph(t)
+	if !assert.Equal(t, int64(1), g.GetShortcutsNum()) {
+		return
+	}
+	check := func(g *Graph) {
+		from, _ := g.FindVertex(1)
+		to, _ := g.FindVertex(2)
+		assert.Equal(t, 10.0, g.originalEdgeCost(from, to))
+		assert.Len(t, g.originalOutIncidentEdges(from), 2)
+		assert.Len(t, g.originalInIncidentEdges(to), 2)
+		assert.Len(t, g.Vertices[from].outIncidentEdges, 3)
+	}
+	check(g)
+
+	var buf bytes.Buffer
+	if !assert.NoError(t, g.ExportBinary(&buf)) {
+		return
+	}
+	imported, err := ImportBinary(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	check(imported)
+
+	var edges, vertices, shortcuts bytes.Buffer
+	if !assert.NoError(t, g.ExportToWriters(&edges, &vertices, &shortcuts)) {
+		return
+	}
+	imported, err = ImportFromReaders(&edges, &vertices, &shortcuts)
+	if !assert.NoError(t, err) {
+		return
+	}
+	check(imported)
+}
@@ -9,8 +9,42 @@ var (
 	ErrGraphIsFrozen = fmt.Errorf("Graph has been frozen")
 	// ErrCHNotPrepared Contraction hierarchies have not been prepared yet.
 	ErrCHNotPrepared = fmt.Errorf("Contraction hierarchies have not been prepared")
+	// ErrCCHNotPrepared Graph has no customizable contraction hierarchies (see PrepareCustomizable() and RecustomizeTriangles()).
+	ErrCCHNotPrepared = fmt.Errorf("Customizable contraction hierarchies have not been prepared")
+	// ErrCHAlreadyPrepared Contraction hierarchies have been prepared already, so they can not be prepared again.
+	ErrCHAlreadyPrepared = fmt.Errorf("Contraction hierarchies have been prepared already")
 	// ErrVertexNotFound Vertex with given label was not found in graph.
 	ErrVertexNotFound = fmt.Errorf("Vertex not found")
 	// ErrEdgeNotFound Edge between given vertices was not found.
 	ErrEdgeNotFound = fmt.Errorf("Edge not found")
+	// ErrBinaryBadMagic Data is not a binary graph file.
+	ErrBinaryBadMagic = fmt.Errorf("Bad magic number of binary graph file")
+	// ErrBinaryUnsupportedVersion Version of binary graph file is not supported by library.
+	ErrBinaryUnsupportedVersion = fmt.Errorf("Unsupported version of binary graph file")
+	// ErrBinaryChecksumMismatch Binary graph file has been damaged.
+	ErrBinaryChecksumMismatch = fmt.Errorf("Checksum mismatch of binary graph file")
+	// ErrBinaryCorrupted Binary graph file contains inconsistent data.
+	ErrBinaryCorrupted = fmt.Errorf("Binary graph file is corrupted")
+	// ErrBinaryTooManyVertices Graph can't be stored in binary format since vertices are addressed by 32-bit IDs.
+	ErrBinaryTooManyVertices = fmt.Errorf("Too many vertices for binary graph file")
+	// ErrEmptyGraph Graph has no vertices.
+	ErrEmptyGraph = fmt.Errorf("Graph has no vertices")
+	// ErrCustomizableNotExportable Customizable contraction hierarchies can not be stored in CSV or binary format.
+	ErrCustomizableNotExportable = fmt.Errorf("Customizable contraction hierarchies can not be exported")
+	// ErrInvalidOrder Contraction order is not a permutation of vertices of graph.
+	ErrInvalidOrder = fmt.Errorf("Invalid contraction order")
+	// ErrMetricNotFound Metric with given name was not added to graph.
+	ErrMetricNotFound = fmt.Errorf("Metric not found")
+	// ErrMetricExists Metric with given name has been added to graph already.
+	ErrMetricExists = fmt.Errorf("Metric already exists")
+	// ErrInvalidTravelTimeFunction Breakpoints of travel time function are invalid.
+	ErrInvalidTravelTimeFunction = fmt.Errorf("Invalid travel time function")
+	// ErrTravelTimeNotFIFO Travel time function violates FIFO property: departing later leads to arriving earlier.
+	ErrTravelTimeNotFIFO = fmt.Errorf("Travel time function violates FIFO property")
+	// ErrInvalidTurnRestriction Turn restriction must contain at least one via vertex.
+	ErrInvalidTurnRestriction = fmt.Errorf("Invalid turn restriction")
+	// ErrInvalidTurnCost Turn cost must be non-negative.
+	ErrInvalidTurnCost = fmt.Errorf("Invalid turn cost")
+	// ErrCheckpointMismatch Checkpoint can't be applied to the graph.
+	ErrCheckpointMismatch = fmt.Errorf("Checkpoint does not match graph")
 )
@@ -3,6 +3,7 @@ package ch
 import (
 	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -24,7 +25,12 @@ import (
 // 		to_vertex_id - int64, ID of target vertex
 // 		weight - float64, Weight of an shortcut
 // 		via_vertex_id - int64, ID of vertex through which the shortcut exists
+//
+// Returns error if graph has customizable contraction hierarchies (see PrepareCustomizable()): they can't be restored from shortcuts.
 func (graph *Graph) ExportToFile(fname string) error {
+	if graph.cch != nil {
+		return ErrCustomizableNotExportable
+	}
 
 	fnamePart := strings.Split(fname, ".csv") // to guarantee proper filename and its extension
 
@@ -48,6 +54,31 @@ func (graph *Graph) ExportToFile(fname string) error {
 	return nil
 }
 
+// ExportToWriters Exports graph in CSV-format to given writers: one for edges, one for vertices and one for shortcuts.
+// See ExportToFile(fname string) for headers description.
+//
+// Useful when graph should be written to something different from plain files: compressed streams, HTTP responses, in-memory buffers and etc.
+//
+// Returns error if graph has customizable contraction hierarchies (see PrepareCustomizable()).
+func (graph *Graph) ExportToWriters(edges, vertices, shortcuts io.Writer) error {
+	if graph.cch != nil {
+		return ErrCustomizableNotExportable
+	}
+	err := graph.ExportEdgesToWriter(edges)
+	if err != nil {
+		return errors.Wrap(err, "Can't export edges")
+	}
+	err = graph.ExportVerticesToWriter(vertices)
+	if err != nil {
+		return errors.Wrap(err, "Can't export vertices")
+	}
+	err = graph.ExportShortcutsToWriter(shortcuts)
+	if err != nil {
+		return errors.Wrap(err, "Can't export shortcuts")
+	}
+	return nil
+}
+
 // ExportVerticesToFile Exports edges information to CSV-file with header:
 // 	from_vertex_id - int64, ID of source vertex
 // 	to_vertex_id - int64, ID of target vertex
@@ -57,12 +88,19 @@ func (graph *Graph) ExportEdgesToFile(fname string) error {
 	if err != nil {
 		return errors.Wrap(err, "Can't create edges file")
 	}
-	defer file.Close()
+	err = graph.ExportEdgesToWriter(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+// ExportEdgesToWriter Exports edges information in CSV-format to given writer. See ExportEdgesToFile(fname string) for header description.
+func (graph *Graph) ExportEdgesToWriter(w io.Writer) error {
+	writer := csv.NewWriter(w)
 	writer.Comma = ';'
-	err = writer.Write([]string{"from_vertex_id", "to_vertex_id", "weight"})
+	err := writer.Write([]string{"from_vertex_id", "to_vertex_id", "weight"})
 	if err != nil {
 		return errors.Wrap(err, "Can't write header to edges file")
 	}
@@ -75,9 +113,8 @@ func (graph *Graph) ExportEdgesToFile(fname string) error {
 		outcomingNeighbors := graph.Vertices[i].outIncidentEdges
 		for j := range outcomingNeighbors {
 			toVertexExternal := graph.Vertices[outcomingNeighbors[j].vertexID].Label
-			toVertexInternal := outcomingNeighbors[j].vertexID
 			cost := outcomingNeighbors[j].weight
-			if _, ok := graph.shortcuts[currentVertexInternal][toVertexInternal]; !ok {
+			if graph.isOriginalOutEdge(currentVertexInternal, j) {
 				err = writer.Write([]string{
 					fmt.Sprintf("%d", currentVertexExternal),
 					fmt.Sprintf("%d", toVertexExternal),
@@ -90,7 +127,8 @@ func (graph *Graph) ExportEdgesToFile(fname string) error {
 		}
 	}
 
-	return nil
+	writer.Flush()
+	return errors.Wrap(writer.Error(), "Can't flush edges information")
 }
 
 // ExportVerticesToFile Exports vertices information to CSV-file with header:
@@ -102,11 +140,19 @@ func (graph *Graph) ExportVerticesToFile(fname string) error {
 	if err != nil {
 		return errors.Wrap(err, "Can't create vertices file")
 	}
-	defer fileVertices.Close()
-	writerVertices := csv.NewWriter(fileVertices)
-	defer writerVertices.Flush()
+	err = graph.ExportVerticesToWriter(fileVertices)
+	if err != nil {
+		fileVertices.Close()
+		return err
+	}
+	return fileVertices.Close()
+}
+
+// ExportVerticesToWriter Exports vertices information in CSV-format to given writer. See ExportVerticesToFile(fname string) for header description.
+func (graph *Graph) ExportVerticesToWriter(w io.Writer) error {
+	writerVertices := csv.NewWriter(w)
 	writerVertices.Comma = ';'
-	err = writerVertices.Write([]string{"vertex_id", "order_pos", "importance"})
+	err := writerVertices.Write([]string{"vertex_id", "order_pos", "importance"})
 	if err != nil {
 		return errors.Wrap(err, "Can't write header to vertices file")
 	}
@@ -121,7 +167,8 @@ func (graph *Graph) ExportVerticesToFile(fname string) error {
 			return errors.Wrap(err, "Can't write vertex information")
 		}
 	}
-	return nil
+	writerVertices.Flush()
+	return errors.Wrap(writerVertices.Error(), "Can't flush vertices information")
 }
 
 // ExportShortcutsToFile Exports shortcuts information to CSV-file with header:
@@ -134,11 +181,24 @@ func (graph *Graph) ExportShortcutsToFile(fname string) error {
 	if err != nil {
 		return errors.Wrap(err, "Can't create shortcuts file")
 	}
-	defer fileShortcuts.Close()
-	writerShortcuts := csv.NewWriter(fileShortcuts)
-	defer writerShortcuts.Flush()
+	err = graph.ExportShortcutsToWriter(fileShortcuts)
+	if err != nil {
+		fileShortcuts.Close()
+		return err
+	}
+	return fileShortcuts.Close()
+}
+
+// ExportShortcutsToWriter Exports shortcuts information in CSV-format to given writer. See ExportShortcutsToFile(fname string) for header description.
+//
+// Returns error if graph has customizable contraction hierarchies (see PrepareCustomizable()).
+func (graph *Graph) ExportShortcutsToWriter(w io.Writer) error {
+	if graph.cch != nil {
+		return ErrCustomizableNotExportable
+	}
+	writerShortcuts := csv.NewWriter(w)
 	writerShortcuts.Comma = ';'
-	err = writerShortcuts.Write([]string{"from_vertex_id", "to_vertex_id", "weight", "via_vertex_id"})
+	err := writerShortcuts.Write([]string{"from_vertex_id", "to_vertex_id", "weight", "via_vertex_id"})
 	if err != nil {
 		return errors.Wrap(err, "Can't write header to shortucts file")
 	}
@@ -158,5 +218,6 @@ func (graph *Graph) ExportShortcutsToFile(fname string) error {
 			}
 		}
 	}
-	return nil
+	writerShortcuts.Flush()
+	return errors.Wrap(writerShortcuts.Error(), "Can't flush shortcuts information")
 }
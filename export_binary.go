@@ -0,0 +1,149 @@
+package ch
+
+import (
+	"bufio"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// binaryMagic Magic number of binary graph file: "CHBG"
+	binaryMagic = uint32(0x47424843)
+	// binaryVersion Current version of binary graph file format
+	binaryVersion = uint16(2)
+	// binaryFlagPrepared Contraction hierarchies had been prepared before export
+	binaryFlagPrepared = uint16(1 << 0)
+)
+
+// ExportBinary Exports graph to compact binary format
+//
+// Layout (all numbers are little-endian):
+//
+//	header:
+//		magic - uint32, "CHBG"
+//		version - uint16, version of format
+//		flags - uint16, bit 0 is set when contraction hierarchies have been prepared
+//		vertices_num - uint64, number of vertices
+//		edges_num - uint64, number of initial edges
+//		adjacency_num - uint64, number of outcoming incident edges (including shortcuts)
+//		shortcuts_num - uint64, number of shortcuts
+//		order_num - uint64, number of vertices in contraction order
+//	vertices (vertices_num times):
+//		label - int64, User's defined ID of vertex
+//		order_pos - int64, Position of vertex in hierarchies
+//		importance - int64, Importance of vertex in graph
+//	adjacency:
+//		degree - uint32 (vertices_num times), number of outcoming incident edges of each vertex
+//		to - uint32, weight - float64, shortcut - uint8 (adjacency_num times), outcoming incident edges grouped by vertex (shortcut is 1 for incident edges of shortcuts)
+//	shortcuts (shortcuts_num times):
+//		from - uint32, to - uint32, via - uint32, cost - float64
+//	contraction order:
+//		vertex - uint32 (order_num times)
+//	checksum - uint32, CRC-32 (IEEE) of all previous bytes
+//
+// Vertices are referenced by library defined (internal) IDs, so there is no need to rebuild them on import.
+//
+// Returns error if graph has customizable contraction hierarchies (see PrepareCustomizable()): use ExportQueryGraph(w io.Writer) to store them for queries.
+func (graph *Graph) ExportBinary(w io.Writer) error {
+	if graph.cch != nil {
+		return ErrCustomizableNotExportable
+	}
+	if int64(len(graph.Vertices)) > math.MaxUint32 {
+		return errors.Wrapf(ErrBinaryTooManyVertices, "Vertices num: %d", len(graph.Vertices))
+	}
+	buffered := bufio.NewWriter(w)
+	checksum := crc32.NewIEEE()
+	bw := newBinaryWriter(io.MultiWriter(buffered, checksum))
+
+	adjacencyNum := 0
+	for i := range graph.Vertices {
+		adjacencyNum += len(graph.Vertices[i].outIncidentEdges)
+	}
+	shortcuts := graph.shortcutsInCreationOrder()
+
+	flags := uint16(0)
+	if graph.chPrepared {
+		flags |= binaryFlagPrepared
+	}
+	bw.uint32(binaryMagic)
+	bw.uint16(binaryVersion)
+	bw.uint16(flags)
+	bw.uint64(uint64(len(graph.Vertices)))
+	bw.uint64(uint64(graph.edgesNum))
+	bw.uint64(uint64(adjacencyNum))
+	bw.uint64(uint64(len(shortcuts)))
+	bw.uint64(uint64(len(graph.contractionOrder)))
+
+	for i := range graph.Vertices {
+		bw.int64(graph.Vertices[i].Label)
+		bw.int64(graph.Vertices[i].orderPos)
+		bw.int64(int64(graph.Vertices[i].importance))
+	}
+
+	for i := range graph.Vertices {
+		bw.uint32(uint32(len(graph.Vertices[i].outIncidentEdges)))
+	}
+	for i := range graph.Vertices {
+		outcomingEdges := graph.Vertices[i].outIncidentEdges
+		for j := range outcomingEdges {
+			bw.uint32(uint32(outcomingEdges[j].vertexID))
+			bw.float64(outcomingEdges[j].weight)
+			bw.uint8(boolToUint8(outcomingEdges[j].shortcut))
+		}
+	}
+
+	for _, shortcut := range shortcuts {
+		bw.uint32(uint32(shortcut.From))
+		bw.uint32(uint32(shortcut.To))
+		bw.uint32(uint32(shortcut.Via))
+		bw.float64(shortcut.Cost)
+	}
+
+	for _, vertexNum := range graph.contractionOrder {
+		bw.uint32(uint32(vertexNum))
+	}
+	if bw.err != nil {
+		return errors.Wrap(bw.err, "Can't write graph data")
+	}
+
+	// Checksum itself is not a part of checksum
+	bw.w = buffered
+	bw.uint32(checksum.Sum32())
+	if bw.err != nil {
+		return errors.Wrap(bw.err, "Can't write checksum")
+	}
+	return buffered.Flush()
+}
+
+// ExportBinaryToFile Exports graph to file of binary format. See ExportBinary(w io.Writer) for details.
+func (graph *Graph) ExportBinaryToFile(fname string) error {
+	file, err := os.Create(fname)
+	if err != nil {
+		return errors.Wrap(err, "Can't create binary file")
+	}
+	err = graph.ExportBinary(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// shortcutsInCreationOrder Returns every shortcut exactly once: grouped by Via-vertex and ordered by creation inside each group
+func (graph *Graph) shortcutsInCreationOrder() []*ShortcutPath {
+	shortcuts := make([]*ShortcutPath, 0, graph.shortcutsNum)
+	for i := range graph.Vertices {
+		for _, shortcut := range graph.shortcutsByVia[int64(i)] {
+			// Shortcut could be replaced by AddShortcut(...) call for the same pair of vertices
+			if graph.shortcuts[shortcut.From][shortcut.To] != shortcut {
+				continue
+			}
+			shortcuts = append(shortcuts, shortcut)
+		}
+	}
+	return shortcuts
+}
@@ -0,0 +1,118 @@
+package ch
+
+import (
+	"bufio"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// queryGraphMagic Magic number of query graph file: "CHQG"
+	queryGraphMagic = uint32(0x47514843)
+	// queryGraphVersion Current version of query graph file format
+	queryGraphVersion = uint16(1)
+	// queryGraphHeaderSize Size of header of query graph file in bytes
+	queryGraphHeaderSize = 32
+)
+
+// ExportQueryGraph Exports read-only query representation of prepared contraction hierarchies.
+// Exported data could be opened via OpenMappedGraph(fname string) without decoding (memory-mapped).
+//
+// Current snapshot of graph is exported (see Snapshot()): weights updated by UpdateEdgeWeight(..., false) are not exported until recustomization.
+//
+// Layout (all numbers are little-endian, every section starts at 8-byte aligned offset):
+//
+//	header:
+//		magic - uint32, "CHQG"
+//		version - uint16, version of format
+//		flags - uint16, reserved
+//		vertices_num - uint64, number of vertices
+//		forward_arcs_num - uint64, number of upward arcs in forward direction
+//		backward_arcs_num - uint64, number of upward arcs in backward direction
+//	labels - int64 (vertices_num times), User's defined IDs of vertices
+//	sorted_labels - int64 (vertices_num times), User's defined IDs of vertices in ascending order
+//	sorted_ids - int64 (vertices_num times), library defined IDs corresponding to sorted_labels
+//	for forward and then for backward direction:
+//		offsets - int64 (vertices_num+1 times), arcs of vertex V are stored in range [offsets[V]; offsets[V+1])
+//		targets - int64 (arcs_num times), library defined IDs of opposite vertices of arcs
+//		weights - float64 (arcs_num times), travel costs of arcs
+//		via - int64 (arcs_num times), library defined IDs of Via-vertices of shortcuts (-1 for initial edges)
+//	checksum - uint32, CRC-32 (IEEE) of all previous bytes
+func (graph *Graph) ExportQueryGraph(w io.Writer) error {
+	if !graph.chPrepared {
+		return ErrCHNotPrepared
+	}
+	q := graph.sharedQueryGraph()
+
+	sortedIDs := make([]int64, len(q.labels))
+	for i := range sortedIDs {
+		sortedIDs[i] = int64(i)
+	}
+	sort.Slice(sortedIDs, func(i, j int) bool {
+		return q.labels[sortedIDs[i]] < q.labels[sortedIDs[j]]
+	})
+
+	buffered := bufio.NewWriter(w)
+	checksum := crc32.NewIEEE()
+	bw := newBinaryWriter(io.MultiWriter(buffered, checksum))
+
+	bw.uint32(queryGraphMagic)
+	bw.uint16(queryGraphVersion)
+	bw.uint16(0)
+	bw.uint64(uint64(len(q.labels)))
+	bw.uint64(uint64(len(q.targets[forward])))
+	bw.uint64(uint64(len(q.targets[backward])))
+
+	for _, label := range q.labels {
+		bw.int64(label)
+	}
+	for _, id := range sortedIDs {
+		bw.int64(q.labels[id])
+	}
+	for _, id := range sortedIDs {
+		bw.int64(id)
+	}
+	for d := forward; d < directionsCount; d++ {
+		for _, offset := range q.offsets[d] {
+			bw.int64(offset)
+		}
+		for _, target := range q.targets[d] {
+			bw.int64(target)
+		}
+		for _, weight := range q.weights[d] {
+			bw.float64(weight)
+		}
+		for _, via := range q.via[d] {
+			bw.int64(via)
+		}
+	}
+	if bw.err != nil {
+		return errors.Wrap(bw.err, "Can't write query graph data")
+	}
+
+	// Checksum itself is not a part of checksum
+	bw.w = buffered
+	bw.uint32(checksum.Sum32())
+	if bw.err != nil {
+		return errors.Wrap(bw.err, "Can't write checksum")
+	}
+	return buffered.Flush()
+}
+
+// ExportQueryGraphToFile Exports read-only query representation to file. See ExportQueryGraph(w io.Writer) for details.
+func (graph *Graph) ExportQueryGraphToFile(fname string) error {
+	file, err := os.Create(fname)
+	if err != nil {
+		return errors.Wrap(err, "Can't create query graph file")
+	}
+	err = graph.ExportQueryGraph(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -2,8 +2,11 @@ package ch
 
 import (
 	"container/heap"
+	"context"
 	"fmt"
 	"log"
+	"sync"
+	"sync/atomic"
 )
 
 // Graph Graph object
@@ -15,6 +18,12 @@ type Graph struct {
 	shortcuts    map[int64]map[int64]*ShortcutPath
 	restrictions map[int64]map[int64]int64
 	mapping      map[int64]int64
+	// Sequences of vertices forbidden by turn restrictions (for edge-based contraction hierarchies, see PrepareEdgeBased(...))
+	turnRestrictions [][]int64
+	// Tables of turn costs of vertices (see AddTurnCost(...))
+	turnCosts map[int64]map[turnKey]float64
+	// Travel time functions of time-dependent edges (see AddTimeDependentEdge(...))
+	travelTimes map[int64]map[int64]*TravelTimeFunction
 
 	Vertices     []Vertex
 	edgesNum     int64
@@ -54,6 +63,18 @@ type Graph struct {
 	contractionOrder []int64
 	// Flag indicating CH has been prepared
 	chPrepared bool
+	// Customizable contraction hierarchies (nil if CH has been prepared with witness searches)
+	cch *customizableHierarchy
+	// Named metrics sharing hierarchies with graph (see AddMetric(...))
+	metrics map[string]*graphMetric
+	// Guards metrics: queries look metrics up concurrently, while changes of metrics are serialized
+	metricsMu sync.RWMutex
+
+	// Current immutable snapshot of CH (*MetricSnapshot) used by QueryPool: lazily built, replaced atomically on recustomization
+	snapshot   atomic.Value
+	snapshotMu sync.Mutex
+	// Order of vertices has been changed since the current snapshot was published: it can't share topology with the next one
+	snapshotTopologyChanged bool
 }
 
 // NewGraph returns pointer to created Graph and does preallocations for processing purposes
@@ -131,6 +152,9 @@ func (graph *Graph) addEdge(from, to int64, weight float64) {
 // to - User's definied ID of last vertex of shortcut
 // via - User's defined ID of vertex through which the shortcut exists
 // weight - User's definied weight of shortcut
+//
+// Incident edges of shortcut must be added by AddEdge(from, to, weight) just before: the last added edge from -> to is marked as shortcut one
+// (so initial edge from -> to added earlier is kept).
 func (graph *Graph) AddShortcut(from, to, via int64, weight float64) error {
 	if graph.frozen {
 		return ErrGraphIsFrozen
@@ -149,17 +173,41 @@ func (graph *Graph) AddShortcut(from, to, via int64, weight float64) error {
 	}
 	graph.shortcuts[fromInternal][toInternal] = shortcut
 	graph.shortcutsNum++
+	graph.markShortcutEdges(fromInternal, toInternal)
 
 	// Track shortcut by Via vertex for recustomization
 	graph.shortcutsByVia[viaInternal] = append(graph.shortcutsByVia[viaInternal], shortcut)
 	return nil
 }
 
+// markShortcutEdges Marks the last initial incident edges from -> to (library defined IDs) as shortcut ones
+func (graph *Graph) markShortcutEdges(from, to int64) {
+	outcomingEdges := graph.Vertices[from].outIncidentEdges
+	for j := len(outcomingEdges) - 1; j >= 0; j-- {
+		if outcomingEdges[j].vertexID == to && !outcomingEdges[j].shortcut {
+			outcomingEdges[j].shortcut = true
+			break
+		}
+	}
+	incomingEdges := graph.Vertices[to].inIncidentEdges
+	for j := len(incomingEdges) - 1; j >= 0; j-- {
+		if incomingEdges[j].vertexID == from && !incomingEdges[j].shortcut {
+			incomingEdges[j].shortcut = true
+			break
+		}
+	}
+}
+
 // PrepareContractionHierarchies Compute contraction hierarchies
-func (graph *Graph) PrepareContractionHierarchies() {
-	pqImportance := graph.computeImportance()
-	graph.Preprocess(pqImportance)
-	graph.Freeze()
+//
+// opts - optional parameters of preparation (e.g. WithWorkers(runtime.NumCPU()) for parallel contraction)
+//
+// If CH has been prepared already then graph is left as is and error is logged (see PrepareContractionHierarchiesContext(...)).
+func (graph *Graph) PrepareContractionHierarchies(opts ...PrepareOption) {
+	// Background context is never cancelled, so the only possible error is about CH prepared already
+	if err := graph.PrepareContractionHierarchiesContext(context.Background(), opts...); err != nil {
+		log.Println(err)
+	}
 }
 
 // SetVerbose sets verbose parameter for debugging purposes
@@ -168,11 +216,13 @@ func (graph *Graph) SetVerbose(flag bool) {
 }
 
 // computeImportance Returns heap to store computed importance of each vertex
-func (graph *Graph) computeImportance() *importanceHeap {
+//
+// ordering - Strategy for computing importance (nil means default one, see Vertex.computeImportance())
+func (graph *Graph) computeImportance(ordering OrderingStrategy) *importanceHeap {
 	pqImportance := &importanceHeap{}
 	heap.Init(pqImportance)
 	for i := range graph.Vertices {
-		graph.Vertices[i].computeImportance()
+		graph.updateImportance(&graph.Vertices[i], ordering)
 		heap.Push(pqImportance, &graph.Vertices[i])
 	}
 	graph.Freeze()
@@ -210,6 +260,8 @@ func (graph *Graph) GetEdgesNum() int64 {
 // from User's definied ID of source vertex
 // via User's definied ID of prohibited vertex (between source and target)
 // to User's definied ID of target vertex
+//
+// Note: VanillaTurnRestrictedShortestPath(...) supports only one restriction for every source vertex. Edge-based contraction hierarchies support any number of them (see PrepareEdgeBased(...)).
 func (graph *Graph) AddTurnRestriction(from, via, to int64) error {
 	if graph.frozen {
 		return ErrGraphIsFrozen
@@ -218,6 +270,7 @@ func (graph *Graph) AddTurnRestriction(from, via, to int64) error {
 	from = graph.mapping[from]
 	via = graph.mapping[via]
 	to = graph.mapping[to]
+	graph.turnRestrictions = append(graph.turnRestrictions, []int64{from, via, to})
 
 	if graph.restrictions == nil {
 		graph.restrictions = make(map[int64]map[int64]int64)
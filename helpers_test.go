@@ -0,0 +1,21 @@
+package ch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// parallelShortcutGraph Returns prepared graph where shortcut 1 -> 2 (via 3) is parallel to more expensive initial edge 1 -> 2
+func parallelShortcutGraph(t *testing.T) *Graph {
+	g := NewGraph()
+	for i := int64(1); i <= 4; i++ {
+		g.CreateVertex(i)
+	}
+	g.AddEdge(1, 2, 10)
+	g.AddEdge(1, 3, 1)
+	g.AddEdge(3, 2, 1)
+	g.AddEdge(2, 4, 10)
+	assert.NoError(t, g.PrepareWithOrder([]int64{3, 1, 2, 4}))
+	return g
+}
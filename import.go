@@ -54,13 +54,30 @@ type CSVHeaderImportShortcuts struct {
 // 		weight - float64, Weight of an shortcut
 // 		via_vertex_id - int64, ID of vertex through which the shortcut exists
 func ImportFromFile(edgesFname, verticesFname, contractionsFname string) (*Graph, error) {
-	// Read edges first
-	file, err := os.Open(edgesFname)
+	fileEdges, err := os.Open(edgesFname)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
-	reader := csv.NewReader(file)
+	defer fileEdges.Close()
+	fileVertices, err := os.Open(verticesFname)
+	if err != nil {
+		return nil, err
+	}
+	defer fileVertices.Close()
+	fileShortcuts, err := os.Open(contractionsFname)
+	if err != nil {
+		return nil, err
+	}
+	defer fileShortcuts.Close()
+	return ImportFromReaders(fileEdges, fileVertices, fileShortcuts)
+}
+
+// ImportFromReaders Imports graph (prepared by ExportToWriters(...) or ExportToFile(fname string) function) from readers of CSV-format
+// See ImportFromFile(...) for headers description.
+//
+// Useful when graph data comes from something different from plain files: embedded assets, compressed streams, HTTP bodies, in-memory buffers and etc.
+func ImportFromReaders(edges, vertices, shortcuts io.Reader) (*Graph, error) {
+	reader := csv.NewReader(edges)
 	reader.Comma = ';'
 
 	graph := Graph{}
@@ -81,6 +98,9 @@ func ImportFromFile(edgesFname, verticesFname, contractionsFname string) (*Graph
 		if err == io.EOF {
 			break
 		}
+		if err != nil {
+			return nil, err
+		}
 		sourceExternal, err := strconv.ParseInt(record[edgesColumns.SourceExternal], 10, 64)
 		if err != nil {
 			return nil, err
@@ -111,12 +131,7 @@ func ImportFromFile(edgesFname, verticesFname, contractionsFname string) (*Graph
 	}
 
 	// Read vertices
-	fileVertices, err := os.Open(verticesFname)
-	if err != nil {
-		return nil, err
-	}
-	defer fileVertices.Close()
-	readerVertices := csv.NewReader(fileVertices)
+	readerVertices := csv.NewReader(vertices)
 	readerVertices.Comma = ';'
 
 	// Skip header of CSV-file
@@ -134,6 +149,9 @@ func ImportFromFile(edgesFname, verticesFname, contractionsFname string) (*Graph
 		if err == io.EOF {
 			break
 		}
+		if err != nil {
+			return nil, err
+		}
 
 		vertexExternal, err := strconv.ParseInt(record[verticesColumns.ID], 10, 64)
 		if err != nil {
@@ -157,12 +175,7 @@ func ImportFromFile(edgesFname, verticesFname, contractionsFname string) (*Graph
 	}
 
 	// Read contractions
-	fileShortcuts, err := os.Open(contractionsFname)
-	if err != nil {
-		return nil, err
-	}
-	defer fileShortcuts.Close()
-	readerShortcuts := csv.NewReader(fileShortcuts)
+	readerShortcuts := csv.NewReader(shortcuts)
 	readerShortcuts.Comma = ';'
 	// Process header of CSV-file
 	shortcutsHeader, err := readerShortcuts.Read()
@@ -179,6 +192,9 @@ func ImportFromFile(edgesFname, verticesFname, contractionsFname string) (*Graph
 		if err == io.EOF {
 			break
 		}
+		if err != nil {
+			return nil, err
+		}
 		sourceExternal, err := strconv.ParseInt(record[shortcutsColumns.SourceExternal], 10, 64)
 		if err != nil {
 			return nil, err
@@ -230,6 +246,7 @@ func ImportFromFile(edgesFname, verticesFname, contractionsFname string) (*Graph
 func (graph *Graph) FinalizeImport() {
 	graph.buildContractionOrder()
 	graph.chPrepared = true
+	graph.publishSnapshot()
 	graph.Freeze()
 }
 
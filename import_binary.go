@@ -0,0 +1,156 @@
+package ch
+
+import (
+	"bufio"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// ImportBinary Imports graph (prepared by ExportBinary(w io.Writer) function) from binary format
+//
+// Returned graph is frozen and (if it had been prepared before export) ready for queries and recustomization.
+func ImportBinary(r io.Reader) (*Graph, error) {
+	checksum := crc32.NewIEEE()
+	buffered := bufio.NewReader(r)
+	br := newBinaryReader(io.TeeReader(buffered, checksum))
+
+	magic := br.uint32()
+	if br.err != nil {
+		return nil, errors.Wrap(br.err, "Can't read header")
+	}
+	if magic != binaryMagic {
+		return nil, ErrBinaryBadMagic
+	}
+	version := br.uint16()
+	if br.err == nil && version != binaryVersion {
+		return nil, errors.Wrapf(ErrBinaryUnsupportedVersion, "Version: %d", version)
+	}
+	flags := br.uint16()
+	verticesNum := br.uint64()
+	edgesNum := br.uint64()
+	adjacencyNum := br.uint64()
+	shortcutsNum := br.uint64()
+	orderNum := br.uint64()
+	if br.err != nil {
+		return nil, errors.Wrap(br.err, "Can't read header")
+	}
+
+	// Counts of header are not trusted before checksum is verified: slices grow as data is actually read
+	graph := NewGraph()
+	graph.edgesNum = int64(edgesNum)
+	for i := uint64(0); i < verticesNum && br.err == nil; i++ {
+		label := br.int64()
+		graph.Vertices = append(graph.Vertices, Vertex{
+			Label:      label,
+			vertexNum:  int64(i),
+			orderPos:   br.int64(),
+			importance: int(br.int64()),
+			distance:   NewDistance(),
+		})
+		graph.mapping[label] = int64(i)
+	}
+	if br.err != nil {
+		return nil, errors.Wrap(br.err, "Can't read vertices")
+	}
+
+	outDegree := make([]uint64, 0, len(graph.Vertices))
+	totalDegree := uint64(0)
+	for range graph.Vertices {
+		degree := uint64(br.uint32())
+		outDegree = append(outDegree, degree)
+		totalDegree += degree
+	}
+	if br.err != nil {
+		return nil, errors.Wrap(br.err, "Can't read edges")
+	}
+	if totalDegree != adjacencyNum {
+		return nil, errors.Wrapf(ErrBinaryCorrupted, "Expected %d edges, but got %d", adjacencyNum, totalDegree)
+	}
+	inDegree := make([]int, len(graph.Vertices))
+	for i := range graph.Vertices {
+		outcomingEdges := make([]incidentEdge, 0)
+		for j := uint64(0); j < outDegree[i] && br.err == nil; j++ {
+			to := int64(br.uint32())
+			if to >= int64(verticesNum) {
+				return nil, errors.Wrapf(ErrBinaryCorrupted, "Edge leads to unknown vertex %d", to)
+			}
+			outcomingEdges = append(outcomingEdges, incidentEdge{vertexID: to, weight: br.float64(), shortcut: br.uint8() != 0})
+			inDegree[to]++
+		}
+		graph.Vertices[i].outIncidentEdges = outcomingEdges
+	}
+	if br.err != nil {
+		return nil, errors.Wrap(br.err, "Can't read edges")
+	}
+	for i := range graph.Vertices {
+		graph.Vertices[i].inIncidentEdges = make([]incidentEdge, 0, inDegree[i])
+	}
+	for i := range graph.Vertices {
+		outcomingEdges := graph.Vertices[i].outIncidentEdges
+		for j := range outcomingEdges {
+			to := outcomingEdges[j].vertexID
+			graph.Vertices[to].inIncidentEdges = append(graph.Vertices[to].inIncidentEdges, incidentEdge{vertexID: int64(i), weight: outcomingEdges[j].weight, shortcut: outcomingEdges[j].shortcut})
+		}
+	}
+
+	for i := uint64(0); i < shortcutsNum; i++ {
+		shortcut := &ShortcutPath{
+			From: int64(br.uint32()),
+			To:   int64(br.uint32()),
+			Via:  int64(br.uint32()),
+			Cost: br.float64(),
+		}
+		if br.err != nil {
+			return nil, errors.Wrap(br.err, "Can't read shortcuts")
+		}
+		if shortcut.From >= int64(verticesNum) || shortcut.To >= int64(verticesNum) || shortcut.Via >= int64(verticesNum) {
+			return nil, errors.Wrapf(ErrBinaryCorrupted, "Shortcut %d -> %d via %d references unknown vertex", shortcut.From, shortcut.To, shortcut.Via)
+		}
+		if _, ok := graph.shortcuts[shortcut.From]; !ok {
+			graph.shortcuts[shortcut.From] = make(map[int64]*ShortcutPath)
+		}
+		graph.shortcuts[shortcut.From][shortcut.To] = shortcut
+		graph.shortcutsByVia[shortcut.Via] = append(graph.shortcutsByVia[shortcut.Via], shortcut)
+	}
+	graph.shortcutsNum = int64(shortcutsNum)
+
+	graph.contractionOrder = make([]int64, 0)
+	for i := uint64(0); i < orderNum && br.err == nil; i++ {
+		vertexNum := int64(br.uint32())
+		if br.err == nil && vertexNum >= int64(verticesNum) {
+			return nil, errors.Wrapf(ErrBinaryCorrupted, "Contraction order contains unknown vertex %d", vertexNum)
+		}
+		graph.contractionOrder = append(graph.contractionOrder, vertexNum)
+	}
+	if br.err != nil {
+		return nil, errors.Wrap(br.err, "Can't read contraction order")
+	}
+
+	// Checksum itself is not a part of checksum
+	expectedChecksum := checksum.Sum32()
+	br.r = buffered
+	storedChecksum := br.uint32()
+	if br.err != nil {
+		return nil, errors.Wrap(br.err, "Can't read checksum")
+	}
+	if storedChecksum != expectedChecksum {
+		return nil, ErrBinaryChecksumMismatch
+	}
+
+	graph.chPrepared = flags&binaryFlagPrepared != 0
+	graph.Freeze()
+	return graph, nil
+}
+
+// ImportBinaryFromFile Imports graph from file of binary format. See ImportBinary(r io.Reader) for details.
+func ImportBinaryFromFile(fname string) (*Graph, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ImportBinary(file)
+}
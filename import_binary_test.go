@@ -0,0 +1,116 @@
+package ch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	g, err := generateSyntheticGraph(32)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	var buf bytes.Buffer
+	err = g.ExportBinary(&buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	imported, err := ImportBinary(&buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.True(t, imported.chPrepared)
+	assert.True(t, imported.frozen)
+	assert.Equal(t, g.GetVerticesNum(), imported.GetVerticesNum())
+	assert.Equal(t, g.GetEdgesNum(), imported.GetEdgesNum())
+	assert.Equal(t, g.GetShortcutsNum(), imported.GetShortcutsNum())
+	assert.Equal(t, g.contractionOrder, imported.contractionOrder)
+	shortcutsByVia := 0
+	for via, shortcuts := range imported.shortcutsByVia {
+		for _, shortcut := range shortcuts {
+			assert.Equal(t, via, shortcut.Via)
+			assert.Equal(t, g.shortcuts[shortcut.From][shortcut.To].Cost, shortcut.Cost)
+		}
+		shortcutsByVia += len(shortcuts)
+	}
+	assert.Equal(t, imported.GetShortcutsNum(), int64(shortcutsByVia))
+
+	for i := range g.Vertices {
+		for j := range g.Vertices {
+			source, target := g.Vertices[i].Label, g.Vertices[j].Label
+			expectedCost, expectedPath := g.ShortestPath(source, target)
+			cost, path := imported.ShortestPath(source, target)
+			if math.Abs(expectedCost-cost) > eps {
+				t.Errorf("Cost of path %d -> %d should be %f, but got %f", source, target, expectedCost, cost)
+				return
+			}
+			if len(expectedPath) != len(path) {
+				t.Errorf("Num of vertices in path %d -> %d should be %d, but got %d", source, target, len(expectedPath), len(path))
+				return
+			}
+		}
+	}
+
+	// Recustomization should work on imported graph as well
+	firstEdge := g.Vertices[0].outIncidentEdges[0]
+	err = imported.UpdateEdgeWeight(g.Vertices[0].Label, g.Vertices[firstEdge.vertexID].Label, 100.0, true)
+	assert.NoError(t, err)
+}
+
+func TestBinaryImportCorrupted(t *testing.T) {
+	g, err := generateSyntheticGraph(8)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var buf bytes.Buffer
+	err = g.ExportBinary(&buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	data := buf.Bytes()
+
+	damaged := make([]byte, len(data))
+	copy(damaged, data)
+	damaged[len(damaged)/2] ^= 0xFF
+	_, err = ImportBinary(bytes.NewReader(damaged))
+	assert.Error(t, err)
+
+	damaged = make([]byte, len(data))
+	copy(damaged, data)
+	damaged[0] = 'X'
+	_, err = ImportBinary(bytes.NewReader(damaged))
+	assert.Equal(t, ErrBinaryBadMagic, err)
+
+	_, err = ImportBinary(bytes.NewReader(data[:len(data)-1]))
+	assert.Error(t, err)
+
+	// Huge counts in header must not lead to huge preallocations
+	verticesNum := binary.LittleEndian.Uint64(data[8:16])
+	for _, pos := range []int{8, 24, 32, 40} {
+		copy(damaged, data)
+		binary.LittleEndian.PutUint64(damaged[pos:], 1<<40)
+		_, err = ImportBinary(bytes.NewReader(damaged))
+		assert.Error(t, err)
+	}
+	copy(damaged, data)
+	binary.LittleEndian.PutUint32(damaged[48+24*verticesNum:], math.MaxUint32)
+	_, err = ImportBinary(bytes.NewReader(damaged))
+	assert.Equal(t, ErrBinaryCorrupted, errors.Cause(err))
+	// Last vertex of contraction order (just before checksum)
+	copy(damaged, data)
+	binary.LittleEndian.PutUint32(damaged[len(damaged)-8:], uint32(verticesNum))
+	_, err = ImportBinary(bytes.NewReader(damaged))
+	assert.Equal(t, ErrBinaryCorrupted, errors.Cause(err))
+}
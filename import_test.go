@@ -1,7 +1,9 @@
 package ch
 
 import (
+	"bytes"
 	"math"
+	"strings"
 	"testing"
 )
 
@@ -125,3 +127,46 @@ func TestImportedFileRecustomization(t *testing.T) {
 
 	t.Logf("Recustomization on imported graph works: initial=%f, after update=%f, restored=%f", initialCost, newCost, restoredCost)
 }
+
+func TestImportFromReaders(t *testing.T) {
+	g, err := generateSyntheticGraph(16)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	var edges, vertices, shortcuts bytes.Buffer
+	err = g.ExportToWriters(&edges, &vertices, &shortcuts)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	imported, err := ImportFromReaders(&edges, &vertices, &shortcuts)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if imported.GetShortcutsNum() != g.GetShortcutsNum() {
+		t.Errorf("Number of contractions should be %d, but got %d", g.GetShortcutsNum(), imported.GetShortcutsNum())
+	}
+	if imported.GetVerticesNum() != g.GetVerticesNum() {
+		t.Errorf("Number of vertices should be %d, but got %d", g.GetVerticesNum(), imported.GetVerticesNum())
+	}
+	for i := range g.Vertices {
+		for j := range g.Vertices {
+			source, target := g.Vertices[i].Label, g.Vertices[j].Label
+			expectedCost, _ := g.ShortestPath(source, target)
+			cost, _ := imported.ShortestPath(source, target)
+			if math.Abs(expectedCost-cost) > eps {
+				t.Errorf("Cost of path %d -> %d should be %f, but got %f", source, target, expectedCost, cost)
+				return
+			}
+		}
+	}
+
+	_, err = ImportFromReaders(strings.NewReader("from_vertex_id;to_vertex_id\n"), &vertices, &shortcuts)
+	if err == nil {
+		t.Error("Import should fail when edges header has not enough columns")
+	}
+}
@@ -4,6 +4,8 @@ package ch
 type incidentEdge struct {
 	vertexID int64
 	weight   float64
+	// shortcut is set for incident edges of shortcuts, so initial edge parallel to shortcut could be told apart from the shortcut itself
+	shortcut bool
 }
 
 // addInIncidentEdge Adds incident edge's to pool of "incoming" edges of given vertex.
@@ -11,7 +13,7 @@ type incidentEdge struct {
 // incomingVertexID - Library defined ID of vertex
 // weight - Travel cost of incoming edge
 func (vertex *Vertex) addInIncidentEdge(incomingVertexID int64, weight float64) {
-	vertex.inIncidentEdges = append(vertex.inIncidentEdges, incidentEdge{incomingVertexID, weight})
+	vertex.inIncidentEdges = append(vertex.inIncidentEdges, incidentEdge{vertexID: incomingVertexID, weight: weight})
 	vertex.bidirectedCached = false
 }
 
@@ -20,50 +22,78 @@ func (vertex *Vertex) addInIncidentEdge(incomingVertexID int64, weight float64)
 // outcomingVertexID - Library defined ID of vertex
 // weight - Travel cost of outcoming edge
 func (vertex *Vertex) addOutIncidentEdge(outcomingVertexID int64, weight float64) {
-	vertex.outIncidentEdges = append(vertex.outIncidentEdges, incidentEdge{outcomingVertexID, weight})
+	vertex.outIncidentEdges = append(vertex.outIncidentEdges, incidentEdge{vertexID: outcomingVertexID, weight: weight})
 	vertex.bidirectedCached = false
 }
 
-// findInIncidentEdge Returns index of incoming incident edge by vertex ID
+// addInShortcutEdge Adds incident edge of shortcut to pool of "incoming" edges of given vertex.
+// See ref. addInIncidentEdge(...)
+func (vertex *Vertex) addInShortcutEdge(incomingVertexID int64, weight float64) {
+	vertex.inIncidentEdges = append(vertex.inIncidentEdges, incidentEdge{vertexID: incomingVertexID, weight: weight, shortcut: true})
+	vertex.bidirectedCached = false
+}
+
+// addOutShortcutEdge Adds incident edge of shortcut to pool of "outcoming" edges of given vertex.
+// See ref. addOutIncidentEdge(...)
+func (vertex *Vertex) addOutShortcutEdge(outcomingVertexID int64, weight float64) {
+	vertex.outIncidentEdges = append(vertex.outIncidentEdges, incidentEdge{vertexID: outcomingVertexID, weight: weight, shortcut: true})
+	vertex.bidirectedCached = false
+}
+
+// findInIncidentEdge Returns index of incoming incident edge by vertex ID (incident edges of shortcuts are skipped)
 // If incoming incident edge is not found then this function returns -1
 func (vertex *Vertex) findInIncidentEdge(vertexID int64) int {
-	for i := range vertex.inIncidentEdges {
-		if vertex.inIncidentEdges[i].vertexID == vertexID {
-			return i
-		}
-	}
-	return -1
+	return findIncidentEdge(vertex.inIncidentEdges, vertexID, false)
 }
 
-// findOutIncidentEdge Returns index of outcoming incident edge by vertex ID on the other side of that edge
+// findOutIncidentEdge Returns index of outcoming incident edge by vertex ID on the other side of that edge (incident edges of shortcuts are skipped)
 // If outcoming incident edge is not found then this function returns -1
 func (vertex *Vertex) findOutIncidentEdge(vertexID int64) int {
-	for i := range vertex.outIncidentEdges {
-		if vertex.outIncidentEdges[i].vertexID == vertexID {
-			return i
-		}
-	}
-	return -1
+	return findIncidentEdge(vertex.outIncidentEdges, vertexID, false)
 }
 
-// updateInIncidentEdge Updates incoming incident edge's cost by vertex ID on the other side of that edge
+// updateInIncidentEdge Updates incoming incident edge's cost by vertex ID on the other side of that edge (incident edges of shortcuts are skipped)
 // If operation is not successful then this function returns False
 func (vertex *Vertex) updateInIncidentEdge(vertexID int64, weight float64) bool {
-	idx := vertex.findInIncidentEdge(vertexID)
-	if idx < 0 {
-		return false
-	}
-	vertex.inIncidentEdges[idx].weight = weight
-	return true
+	return updateIncidentEdge(vertex.inIncidentEdges, vertexID, weight, false)
 }
 
-// updateOutIncidentEdge Updates outcoming incident edge's cost by vertex ID on the other side of that edge
+// updateOutIncidentEdge Updates outcoming incident edge's cost by vertex ID on the other side of that edge (incident edges of shortcuts are skipped)
 // If operation is not successful then this function returns False
 func (vertex *Vertex) updateOutIncidentEdge(vertexID int64, weight float64) bool {
-	idx := vertex.findOutIncidentEdge(vertexID)
+	return updateIncidentEdge(vertex.outIncidentEdges, vertexID, weight, false)
+}
+
+// updateInShortcutEdge Updates cost of incoming incident edge of shortcut by vertex ID on the other side of that edge
+// If operation is not successful then this function returns False
+func (vertex *Vertex) updateInShortcutEdge(vertexID int64, weight float64) bool {
+	return updateIncidentEdge(vertex.inIncidentEdges, vertexID, weight, true)
+}
+
+// updateOutShortcutEdge Updates cost of outcoming incident edge of shortcut by vertex ID on the other side of that edge
+// If operation is not successful then this function returns False
+func (vertex *Vertex) updateOutShortcutEdge(vertexID int64, weight float64) bool {
+	return updateIncidentEdge(vertex.outIncidentEdges, vertexID, weight, true)
+}
+
+// findIncidentEdge Returns index of incident edge by vertex ID on the other side of that edge and shortcut flag
+// If incident edge is not found then this function returns -1
+func findIncidentEdge(edges []incidentEdge, vertexID int64, shortcut bool) int {
+	for i := range edges {
+		if edges[i].vertexID == vertexID && edges[i].shortcut == shortcut {
+			return i
+		}
+	}
+	return -1
+}
+
+// updateIncidentEdge Updates incident edge's cost by vertex ID on the other side of that edge and shortcut flag
+// If operation is not successful then this function returns False
+func updateIncidentEdge(edges []incidentEdge, vertexID int64, weight float64, shortcut bool) bool {
+	idx := findIncidentEdge(edges, vertexID, shortcut)
 	if idx < 0 {
 		return false
 	}
-	vertex.outIncidentEdges[idx].weight = weight
+	edges[idx].weight = weight
 	return true
 }
@@ -11,6 +11,7 @@ import (
 // See ref. https://wiki.openstreetmap.org/wiki/Isochrone and https://en.wikipedia.org/wiki/Isochrone_map
 // Note: implemented breadth-first searching path algorithm does not guarantee shortest pathes to reachable vertices (until all edges have cost 1.0). See ref: https://en.wikipedia.org/wiki/Breadth-first_search
 // Note: result for estimated costs could be also inconsistent due nature of data structure
+// Note: use IsochronesExact(...) for exact costs and frontier edges
 func (graph *Graph) Isochrones(source int64, maxCost float64) (map[int64]float64, error) {
 	var ok bool
 	if source, ok = graph.mapping[source]; !ok {
@@ -0,0 +1,159 @@
+package ch
+
+import (
+	"container/heap"
+
+	"github.com/pkg/errors"
+)
+
+// IsochroneDirection Direction of isochrone search
+type IsochroneDirection int
+
+const (
+	// IsochroneForward Isochrone contains vertices which could be reached from source
+	IsochroneForward IsochroneDirection = iota
+	// IsochroneBackward Isochrone contains vertices from which source could be reached (catchment area)
+	IsochroneBackward
+)
+
+// IsochroneFrontierEdge Edge which crosses boundary of isochrone: it can't be traversed entirely within maximum travel cost
+//
+// From - User's definied ID of start vertex of edge
+// To - User's definied ID of end vertex of edge
+// Weight - Weight of edge
+// Position - Fraction of edge (counted from start vertex) where maximum travel cost is reached.
+// For forward isochrones it is (maxCost - cost of From) / Weight in [0, 1), for backward ones it is 1 - (maxCost - cost of To) / Weight in (0, 1]
+type IsochroneFrontierEdge struct {
+	From     int64
+	To       int64
+	Weight   float64
+	Position float64
+}
+
+// NearestSource Nearest source vertex of some vertex in multi-source isochrones. See IsochronesMultiSource(...)
+//
+// Source - User's definied ID of the nearest source vertex
+// Cost - Cost of shortest path between vertex and the nearest source
+type NearestSource struct {
+	Source int64
+	Cost   float64
+}
+
+// IsochronesExact Returns set of vertices and corresponding costs of shortest paths restricted by maximum travel cost for source vertex and frontier edges of isochrone
+//
+// source - source vertex (user defined label)
+// maxCost - restriction on travel cost
+//
+// Unlike Isochrones(...) costs are exact: Dijkstra's algorithm on initial edges (shortcuts are ignored) is stopped when maximum travel cost is exceeded.
+// Every edge which starts in isochrone and can't be traversed entirely within maximum travel cost is frontier one (even if its end is reachable by another path),
+// frontier edges are given in order of settling of their start vertices.
+// See ref. https://wiki.openstreetmap.org/wiki/Isochrone and https://en.wikipedia.org/wiki/Isochrone_map
+func (graph *Graph) IsochronesExact(source int64, maxCost float64) (map[int64]float64, []IsochroneFrontierEdge, error) {
+	return graph.IsochronesWithDirection(source, maxCost, IsochroneForward)
+}
+
+// IsochronesWithDirection Returns set of vertices and corresponding costs of shortest paths restricted by maximum travel cost for source vertex and frontier edges of isochrone in given direction
+//
+// source - source vertex (user defined label)
+// maxCost - restriction on travel cost
+// direction - IsochroneForward for vertices reachable from source (the same as IsochronesExact(...)), IsochroneBackward for vertices from which source is reachable
+//
+// Backward isochrone is forward one on reversed graph: costs are costs of paths to source, frontier edges end in isochrone and can't be traversed entirely within maximum travel cost.
+func (graph *Graph) IsochronesWithDirection(source int64, maxCost float64, direction IsochroneDirection) (map[int64]float64, []IsochroneFrontierEdge, error) {
+	sourceInternal, ok := graph.mapping[source]
+	if !ok {
+		return nil, nil, errors.Wrapf(ErrVertexNotFound, "Source %d", source)
+	}
+	costs, _, frontier := graph.isochrones([]int64{sourceInternal}, maxCost, direction, true)
+	distance := make(map[int64]float64, len(costs))
+	for vertex, cost := range costs {
+		distance[graph.Vertices[vertex].Label] = cost
+	}
+	return distance, frontier, nil
+}
+
+// IsochronesMultiSource Returns set of vertices restricted by maximum travel cost for multiple sources where every vertex is labeled by the nearest source (network Voronoi partition)
+//
+// sources - source vertices (user defined labels)
+// maxCost - restriction on travel cost
+// direction - IsochroneForward for paths from sources, IsochroneBackward for paths to sources
+//
+// Single multi-source Dijkstra's algorithm on initial edges is used. If several sources are equally near then the first of them (in order of sources) is chosen.
+func (graph *Graph) IsochronesMultiSource(sources []int64, maxCost float64, direction IsochroneDirection) (map[int64]NearestSource, error) {
+	sourcesInternal := make([]int64, len(sources))
+	for i, source := range sources {
+		var ok bool
+		if sourcesInternal[i], ok = graph.mapping[source]; !ok {
+			return nil, errors.Wrapf(ErrVertexNotFound, "Source %d", source)
+		}
+	}
+	costs, owners, _ := graph.isochrones(sourcesInternal, maxCost, direction, false)
+	nearest := make(map[int64]NearestSource, len(costs))
+	for vertex, cost := range costs {
+		nearest[graph.Vertices[vertex].Label] = NearestSource{Source: sources[owners[vertex]], Cost: cost}
+	}
+	return nearest, nil
+}
+
+// isochrones Multi-source Dijkstra's algorithm on initial edges from sources (library defined IDs) restricted by maximum travel cost.
+// Returns costs of settled vertices, indices of their nearest sources and frontier edges (if needed)
+func (graph *Graph) isochrones(sources []int64, maxCost float64, direction IsochroneDirection, withFrontier bool) (map[int64]float64, map[int64]int, []IsochroneFrontierEdge) {
+	costs := make(map[int64]float64)
+	owners := make(map[int64]int)
+	var frontier []IsochroneFrontierEdge
+	if withFrontier {
+		frontier = make([]IsochroneFrontierEdge, 0)
+	}
+	if maxCost < 0 {
+		return costs, owners, frontier
+	}
+	queue := &minHeap{}
+	for i, source := range sources {
+		if _, ok := costs[source]; ok {
+			continue
+		}
+		costs[source] = 0
+		owners[source] = i
+		queue.add_with_priority(source, 0)
+	}
+	for queue.Len() != 0 {
+		u := heap.Pop(queue).(*minHeapVertex)
+		if u.distance > costs[u.id] {
+			// Outdated item of queue
+			continue
+		}
+		var vertexList []incidentEdge
+		if direction == IsochroneBackward {
+			vertexList = graph.originalInIncidentEdges(u.id)
+		} else {
+			vertexList = graph.originalOutIncidentEdges(u.id)
+		}
+		for _, edge := range vertexList {
+			neighbor := edge.vertexID
+			alt := u.distance + edge.weight
+			if alt > maxCost {
+				if withFrontier {
+					frontierEdge := IsochroneFrontierEdge{
+						From:     graph.Vertices[u.id].Label,
+						To:       graph.Vertices[neighbor].Label,
+						Weight:   edge.weight,
+						Position: (maxCost - u.distance) / edge.weight,
+					}
+					if direction == IsochroneBackward {
+						frontierEdge.From, frontierEdge.To = frontierEdge.To, frontierEdge.From
+						frontierEdge.Position = 1 - frontierEdge.Position
+					}
+					frontier = append(frontier, frontierEdge)
+				}
+				continue
+			}
+			// Ties are resolved in favor of the first source
+			if cost, ok := costs[neighbor]; !ok || alt < cost || (alt == cost && owners[u.id] < owners[neighbor]) {
+				costs[neighbor] = alt
+				owners[neighbor] = owners[u.id]
+				queue.add_with_priority(neighbor, alt)
+			}
+		}
+	}
+	return costs, owners, frontier
+}
@@ -0,0 +1,204 @@
+package ch
+
+import (
+	"math"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsochronesExact(t *testing.T) {
+	graph := NewGraph()
+	vertices := []V{
+		{from: 5, to: 3, weight: 1.0},
+		{from: 5, to: 4, weight: 1.0},
+		{from: 5, to: 6, weight: 1.0},
+		{from: 5, to: 7, weight: 2.0},
+		{from: 3, to: 7, weight: 2.0},
+		{from: 6, to: 9, weight: 1.0},
+		{from: 7, to: 8, weight: 4.0},
+		{from: 7, to: 3, weight: 2.0},
+		{from: 9, to: 8, weight: 2.0},
+		{from: 8, to: 10, weight: 3.0},
+		{from: 3, to: 1, weight: 2.0},
+		{from: 1, to: 2, weight: 3.0},
+		{from: 4, to: 11, weight: 7.0},
+		{from: 11, to: 2, weight: 2.0},
+		{from: 2, to: 11, weight: 2.0},
+	}
+	for i := range vertices {
+		graph.CreateVertex(vertices[i].from)
+		graph.CreateVertex(vertices[i].to)
+		assert.NoError(t, graph.AddEdge(vertices[i].from, vertices[i].to, vertices[i].weight))
+	}
+	graph.PrepareContractionHierarchies()
+
+	isochrones, frontier, err := graph.IsochronesExact(5, 5.0)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, map[int64]float64{5: 0, 3: 1, 4: 1, 6: 1, 7: 2, 9: 2, 1: 3, 8: 4}, isochrones)
+	if assert.Len(t, frontier, 4) {
+		expected := map[[2]int64]float64{{7, 8}: 3.0 / 4.0, {1, 2}: 2.0 / 3.0, {8, 10}: 1.0 / 3.0, {4, 11}: 4.0 / 7.0}
+		for _, edge := range frontier {
+			position, ok := expected[[2]int64{edge.From, edge.To}]
+			if assert.True(t, ok, "Edge %d -> %d should not be frontier one", edge.From, edge.To) {
+				assert.InDelta(t, position, edge.Position, eps)
+			}
+		}
+	}
+
+	_, _, err = graph.IsochronesExact(100, 5.0)
+	assert.Equal(t, ErrVertexNotFound, errors.Cause(err))
+}
+
+func TestIsochronesExactGrid(t *testing.T) {
+	const size, maxCost = 10, 9.5
+	g := generateGridGraph(size)
+	g.PrepareContractionHierarchies()
+	source := int64(44)
+	isochrones, frontier, err := g.IsochronesExact(source, maxCost)
+	if !assert.NoError(t, err) {
+		return
+	}
+	costs := g.ShortestPathsOneToAll(source)
+	frontierNum := 0
+	for i := range g.Vertices {
+		label := g.Vertices[i].Label
+		cost, ok := isochrones[label]
+		if costs[i] > maxCost {
+			assert.False(t, ok, "Vertex %d should not be in isochrone", label)
+			continue
+		}
+		if !ok || math.Abs(costs[i]-cost) > eps {
+			t.Errorf("Cost of path to vertex %d should be %f, but got %f", label, costs[i], cost)
+			return
+		}
+		for _, edge := range g.originalOutIncidentEdges(int64(i)) {
+			if costs[i]+edge.weight > maxCost {
+				frontierNum++
+			}
+		}
+	}
+	assert.Len(t, frontier, frontierNum)
+	for _, edge := range frontier {
+		from, _ := g.FindVertex(edge.From)
+		assert.InDelta(t, maxCost, costs[from]+edge.Position*edge.Weight, eps)
+		assert.True(t, edge.Position >= 0 && edge.Position < 1)
+	}
+}
+
+func TestIsochronesBackward(t *testing.T) {
+	const size, maxCost = 8, 7.5
+	for _, customizable := range []bool{false, true} {
+		g := generateGridGraph(size)
+		if customizable {
+			if !assert.NoError(t, g.PrepareCustomizable()) {
+				return
+			}
+		} else {
+			g.PrepareContractionHierarchies()
+		}
+		target := int64(27)
+		isochrones, frontier, err := g.IsochronesWithDirection(target, maxCost, IsochroneBackward)
+		if !assert.NoError(t, err) {
+			return
+		}
+		costs := make(map[int64]float64)
+		frontierNum := 0
+		for i := range g.Vertices {
+			label := g.Vertices[i].Label
+			expectedCost, _ := g.ShortestPath(label, target)
+			costs[label] = expectedCost
+			cost, ok := isochrones[label]
+			if expectedCost > maxCost {
+				assert.False(t, ok, "Vertex %d should not be in isochrone", label)
+				continue
+			}
+			if !ok || math.Abs(expectedCost-cost) > eps {
+				t.Errorf("Cost of path from vertex %d should be %f, but got %f", label, expectedCost, cost)
+				return
+			}
+			for _, edge := range g.originalInIncidentEdges(int64(i)) {
+				if expectedCost+edge.weight > maxCost {
+					frontierNum++
+				}
+			}
+		}
+		assert.Len(t, frontier, frontierNum)
+		for _, edge := range frontier {
+			assert.InDelta(t, maxCost, costs[edge.To]+(1-edge.Position)*edge.Weight, eps)
+			assert.True(t, edge.Position > 0 && edge.Position <= 1)
+		}
+	}
+}
+
+func TestIsochronesMultiSource(t *testing.T) {
+	const size, maxCost = 10, 6.0
+	g := generateGridGraph(size)
+	g.PrepareContractionHierarchies()
+	sources := []int64{0, 55, 55, 99, 38}
+	for _, direction := range []IsochroneDirection{IsochroneForward, IsochroneBackward} {
+		nearest, err := g.IsochronesMultiSource(sources, maxCost, direction)
+		if !assert.NoError(t, err) {
+			return
+		}
+		for i := range g.Vertices {
+			label := g.Vertices[i].Label
+			expected := NearestSource{Source: -1, Cost: Infinity}
+			for _, source := range sources {
+				from, to := source, label
+				if direction == IsochroneBackward {
+					from, to = label, source
+				}
+				cost, _ := g.ShortestPath(from, to)
+				if cost >= 0 && cost < expected.Cost {
+					expected = NearestSource{Source: source, Cost: cost}
+				}
+			}
+			result, ok := nearest[label]
+			if expected.Cost > maxCost {
+				assert.False(t, ok, "Vertex %d should not be in isochrone", label)
+				continue
+			}
+			if !ok || result.Source != expected.Source || math.Abs(expected.Cost-result.Cost) > eps {
+				t.Errorf("Nearest source of vertex %d should be %v, but got %v", label, expected, result)
+				return
+			}
+		}
+	}
+
+	_, err := g.IsochronesMultiSource([]int64{0, 1000}, maxCost, IsochroneForward)
+	assert.Equal(t, ErrVertexNotFound, errors.Cause(err))
+}
+
+func TestIsochronesExactParallelShortcut(t *testing.T) {
+	// Shortcut 1 -> 2 (via 3) is parallel to initial edge 1 -> 2
+	g := parallelShortcutGraph(t)
+	isochrones, frontier, err := g.IsochronesExact(1, 4.0)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, map[int64]float64{1: 0, 3: 1, 2: 2}, isochrones)
+	if assert.Len(t, frontier, 2) {
+		assert.Equal(t, IsochroneFrontierEdge{From: 1, To: 2, Weight: 10, Position: 0.4}, frontier[0])
+		assert.Equal(t, IsochroneFrontierEdge{From: 2, To: 4, Weight: 10, Position: 0.2}, frontier[1])
+	}
+}
+
+func TestIsochronesBackwardParallelShortcut(t *testing.T) {
+	// Shortcut 1 -> 2 (via 3) is parallel to initial edge 1 -> 2
+	g := parallelShortcutGraph(t)
+	isochrones, frontier, err := g.IsochronesWithDirection(2, 4.0, IsochroneBackward)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, map[int64]float64{2: 0, 3: 1, 1: 2}, isochrones)
+	if assert.Len(t, frontier, 1) {
+		assert.Equal(t, int64(1), frontier[0].From)
+		assert.Equal(t, int64(2), frontier[0].To)
+		assert.Equal(t, 10.0, frontier[0].Weight)
+		assert.InDelta(t, 0.6, frontier[0].Position, eps)
+	}
+}
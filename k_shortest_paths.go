@@ -0,0 +1,220 @@
+package ch
+
+import (
+	"container/heap"
+	"fmt"
+)
+
+// KShortestPaths Computes and returns up to k shortest loopless paths (and their costs) in ascending order of cost (Yen's algorithm)
+//
+// If there are some errors (or there is no path at all) then function returns nil costs and nil paths.
+//
+// source - User's definied ID of source vertex
+// target - User's definied ID of target vertex
+// k - Maximum number of paths
+//
+// Every path is expanded to initial edges (see ComputePath(...)), paths are distinct sequences of vertices.
+// Spur paths are computed by CH queries when contraction hierarchies have been prepared: if found path does not touch masked edges and vertices
+// (and its cost on initial edges is the same as found one), it is the answer.
+// Otherwise (or if CH has not been prepared) Dijkstra's algorithm on initial edges with masked edges and vertices is used.
+//
+// https://en.wikipedia.org/wiki/Yen%27s_algorithm
+func (graph *Graph) KShortestPaths(source, target int64, k int) ([]float64, [][]int64) {
+	if k < 1 {
+		return nil, nil
+	}
+	sourceInternal, ok := graph.mapping[source]
+	if !ok {
+		return nil, nil
+	}
+	targetInternal, ok := graph.mapping[target]
+	if !ok {
+		return nil, nil
+	}
+	if sourceInternal == targetInternal {
+		return []float64{0}, [][]int64{{source}}
+	}
+
+	firstCost, firstPath := graph.spurPath(sourceInternal, targetInternal, nil, nil)
+	if firstPath == nil {
+		return nil, nil
+	}
+	found := []kPath{{cost: firstCost, vertices: firstPath}}
+	candidates := &kPathHeap{}
+	seen := map[string]struct{}{pathKey(firstPath): {}}
+	for len(found) < k {
+		previous := found[len(found)-1].vertices
+		rootCost := 0.0
+		for i := 0; i+1 < len(previous); i++ {
+			spur := previous[i]
+			root := previous[:i+1]
+			// Edges which continue the same root in already found paths are masked, as well as root vertices (except spur one)
+			removedEdges := make(map[EdgeKey]struct{})
+			for _, path := range found {
+				if len(path.vertices) > i+1 && equalPaths(path.vertices[:i+1], root) {
+					removedEdges[EdgeKey{From: path.vertices[i], To: path.vertices[i+1]}] = struct{}{}
+				}
+			}
+			removedVertices := make(map[int64]struct{}, i)
+			for _, vertex := range root[:i] {
+				removedVertices[vertex] = struct{}{}
+			}
+			spurCost, spurPath := graph.spurPath(spur, targetInternal, removedEdges, removedVertices)
+			if spurPath != nil {
+				candidate := make([]int64, 0, i+len(spurPath))
+				candidate = append(candidate, root[:i]...)
+				candidate = append(candidate, spurPath...)
+				key := pathKey(candidate)
+				if _, ok := seen[key]; !ok {
+					seen[key] = struct{}{}
+					heap.Push(candidates, kPath{cost: rootCost + spurCost, vertices: candidate})
+				}
+			}
+			rootCost += graph.originalEdgeCost(previous[i], previous[i+1])
+		}
+		if candidates.Len() == 0 {
+			break
+		}
+		found = append(found, heap.Pop(candidates).(kPath))
+	}
+
+	costs := make([]float64, len(found))
+	paths := make([][]int64, len(found))
+	for i, path := range found {
+		costs[i] = path.cost
+		paths[i] = make([]int64, len(path.vertices))
+		for j, vertex := range path.vertices {
+			paths[i][j] = graph.Vertices[vertex].Label
+		}
+	}
+	return costs, paths
+}
+
+// kPath Path (library defined IDs of vertices) and its cost
+type kPath struct {
+	cost     float64
+	vertices []int64
+}
+
+type kPathHeap []kPath
+
+func (h kPathHeap) Len() int            { return len(h) }
+func (h kPathHeap) Less(i, j int) bool  { return h[i].cost < h[j].cost }
+func (h kPathHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *kPathHeap) Push(x interface{}) { *h = append(*h, x.(kPath)) }
+func (h *kPathHeap) Pop() interface{} {
+	heapSize := len(*h)
+	lastNode := (*h)[heapSize-1]
+	*h = (*h)[0 : heapSize-1]
+	return lastNode
+}
+
+// spurPath Returns shortest path from source to target (library defined IDs) which avoids removed edges and vertices. Returns nil path if there is no such path
+func (graph *Graph) spurPath(source, target int64, removedEdges map[EdgeKey]struct{}, removedVertices map[int64]struct{}) (float64, []int64) {
+	if graph.chPrepared {
+		cost, labels := graph.shortestPath([directionsCount]int64{source, target})
+		if labels == nil {
+			// Masking can't make path appear
+			return -1.0, nil
+		}
+		path := make([]int64, len(labels))
+		masked := false
+		// Cost of unpacked path is recomputed on initial edges: shortcut could be unpacked even if initial edge parallel to it is cheaper
+		pathCost := 0.0
+		for i, label := range labels {
+			path[i] = graph.mapping[label]
+			if _, ok := removedVertices[path[i]]; ok {
+				masked = true
+			}
+			if i > 0 {
+				if _, ok := removedEdges[EdgeKey{From: path[i-1], To: path[i]}]; ok {
+					masked = true
+				}
+				edgeCost := graph.originalEdgeCost(path[i-1], path[i])
+				if edgeCost < 0 {
+					masked = true
+				}
+				pathCost += edgeCost
+			}
+		}
+		if !masked && pathCost <= cost {
+			return pathCost, path
+		}
+	}
+	return graph.restrictedShortestPath(source, target, removedEdges, removedVertices)
+}
+
+// restrictedShortestPath Dijkstra's algorithm on initial edges which avoids removed edges and vertices (library defined IDs). Returns nil path if there is no path
+func (graph *Graph) restrictedShortestPath(source, target int64, removedEdges map[EdgeKey]struct{}, removedVertices map[int64]struct{}) (float64, []int64) {
+	distance := make(map[int64]float64)
+	prev := make(map[int64]int64)
+	distance[source] = 0
+	queue := &minHeap{}
+	queue.add_with_priority(source, 0)
+	for queue.Len() != 0 {
+		u := heap.Pop(queue).(*minHeapVertex)
+		if u.distance > distance[u.id] {
+			// Outdated item of queue
+			continue
+		}
+		if u.id == target {
+			break
+		}
+		for _, edge := range graph.originalOutIncidentEdges(u.id) {
+			neighbor := edge.vertexID
+			if _, ok := removedVertices[neighbor]; ok {
+				continue
+			}
+			if _, ok := removedEdges[EdgeKey{From: u.id, To: neighbor}]; ok {
+				continue
+			}
+			alt := u.distance + edge.weight
+			if d, ok := distance[neighbor]; !ok || alt < d {
+				distance[neighbor] = alt
+				prev[neighbor] = u.id
+				queue.add_with_priority(neighbor, alt)
+			}
+		}
+	}
+	cost, ok := distance[target]
+	if !ok {
+		return -1.0, nil
+	}
+	path := []int64{target}
+	for u := target; u != source; {
+		u = prev[u]
+		path = append(path, u)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return cost, path
+}
+
+// originalEdgeCost Returns the cheapest weight of initial edges from -> to (library defined IDs). Returns -1 if there is no such edge
+func (graph *Graph) originalEdgeCost(from, to int64) float64 {
+	cost := -1.0
+	for _, edge := range graph.originalOutIncidentEdges(from) {
+		if edge.vertexID == to && (cost < 0 || edge.weight < cost) {
+			cost = edge.weight
+		}
+	}
+	return cost
+}
+
+func equalPaths(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// pathKey Returns string representation of path for deduplication
+func pathKey(path []int64) string {
+	return fmt.Sprint(path)
+}
@@ -0,0 +1,157 @@
+package ch
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKShortestPaths(t *testing.T) {
+	// Example from https://en.wikipedia.org/wiki/Yen%27s_algorithm: C=1, D=2, E=3, F=4, G=5, H=6
+	g := NewGraph()
+	for i := int64(1); i <= 6; i++ {
+		g.CreateVertex(i)
+	}
+	g.AddEdge(1, 2, 3)
+	g.AddEdge(1, 3, 2)
+	g.AddEdge(2, 4, 4)
+	g.AddEdge(3, 2, 1)
+	g.AddEdge(3, 4, 2)
+	g.AddEdge(3, 5, 3)
+	g.AddEdge(4, 5, 2)
+	g.AddEdge(4, 6, 1)
+	g.AddEdge(5, 6, 2)
+	g.PrepareContractionHierarchies()
+
+	costs, paths := g.KShortestPaths(1, 6, 3)
+	assert.Equal(t, []float64{5, 7, 8}, costs)
+	if assert.Len(t, paths, 3) {
+		assert.Equal(t, []int64{1, 3, 4, 6}, paths[0])
+		assert.Equal(t, []int64{1, 3, 5, 6}, paths[1])
+		assert.Contains(t, [][]int64{{1, 2, 4, 6}, {1, 3, 2, 4, 6}, {1, 3, 4, 5, 6}}, paths[2])
+	}
+
+	// There are 7 loopless paths only
+	costs, paths = g.KShortestPaths(1, 6, 100)
+	assert.Equal(t, []float64{5, 7, 8, 8, 8, 11, 11}, costs)
+	assert.Len(t, paths, 7)
+
+	costs, paths = g.KShortestPaths(6, 1, 3)
+	assert.Nil(t, costs)
+	assert.Nil(t, paths)
+	costs, paths = g.KShortestPaths(1, 100, 3)
+	assert.Nil(t, costs)
+	assert.Nil(t, paths)
+	costs, paths = g.KShortestPaths(1, 1, 3)
+	assert.Equal(t, []float64{0}, costs)
+	assert.Equal(t, [][]int64{{1}}, paths)
+}
+
+func TestKShortestPathsGrid(t *testing.T) {
+	const width, height, k = 4, 3, 15
+	g := generateGridGraph(width)
+	// Take the first rows of grid only
+	sub := NewGraph()
+	for v := int64(0); v < width*height; v++ {
+		sub.CreateVertex(v)
+	}
+	for v := range g.Vertices {
+		if g.Vertices[v].Label >= width*height {
+			continue
+		}
+		for _, edge := range g.Vertices[v].outIncidentEdges {
+			if to := g.Vertices[edge.vertexID].Label; to < width*height {
+				sub.AddEdge(g.Vertices[v].Label, to, edge.weight)
+			}
+		}
+	}
+	notPrepared := NewGraph()
+	for v := int64(0); v < width*height; v++ {
+		notPrepared.CreateVertex(v)
+	}
+	for v := range sub.Vertices {
+		for _, edge := range sub.Vertices[v].outIncidentEdges {
+			notPrepared.AddEdge(sub.Vertices[v].Label, sub.Vertices[edge.vertexID].Label, edge.weight)
+		}
+	}
+	expected := allSimplePathCosts(sub, 0, width*height-1)
+	sub.PrepareContractionHierarchies()
+
+	for _, graph := range []*Graph{sub, notPrepared} {
+		costs, paths := graph.KShortestPaths(0, width*height-1, k)
+		if !assert.Len(t, costs, k) {
+			return
+		}
+		seen := make(map[string]struct{})
+		for i := range costs {
+			if math.Abs(expected[i]-costs[i]) > eps {
+				t.Errorf("Cost of path #%d should be %f, but got %f", i, expected[i], costs[i])
+				return
+			}
+			// Path is loopless, distinct and has given cost
+			key := pathKey(paths[i])
+			_, ok := seen[key]
+			assert.False(t, ok, "Path %v is duplicated", paths[i])
+			seen[key] = struct{}{}
+			visited := make(map[int64]struct{})
+			pathCost := 0.0
+			for j, vertex := range paths[i] {
+				_, ok := visited[vertex]
+				assert.False(t, ok, "Path %v has loop", paths[i])
+				visited[vertex] = struct{}{}
+				if j > 0 {
+					from, _ := graph.FindVertex(paths[i][j-1])
+					to, _ := graph.FindVertex(vertex)
+					pathCost += graph.originalEdgeCost(from, to)
+				}
+			}
+			assert.InDelta(t, costs[i], pathCost, eps)
+		}
+	}
+}
+
+// allSimplePathCosts Returns sorted costs of all loopless paths between two vertices (depth-first search)
+func allSimplePathCosts(g *Graph, source, target int64) []float64 {
+	costs := make([]float64, 0)
+	visited := make(map[int64]bool)
+	target = g.mapping[target]
+	var dfs func(vertex int64, cost float64)
+	dfs = func(vertex int64, cost float64) {
+		if vertex == target {
+			costs = append(costs, cost)
+			return
+		}
+		visited[vertex] = true
+		for _, edge := range g.Vertices[vertex].outIncidentEdges {
+			if !visited[edge.vertexID] {
+				dfs(edge.vertexID, cost+edge.weight)
+			}
+		}
+		visited[vertex] = false
+	}
+	dfs(g.mapping[source], 0)
+	sort.Float64s(costs)
+	return costs
+}
+
+func TestKShortestPathsParallelShortcut(t *testing.T) {
+	// Shortcut 1 -> 2 (via 3) is parallel to initial edge 1 -> 2
+	g := parallelShortcutGraph(t)
+	assert.Equal(t, 10.0, g.originalEdgeCost(g.mapping[1], g.mapping[2]))
+	costs, paths := g.KShortestPaths(1, 2, 5)
+	assert.Equal(t, []float64{2, 10}, costs)
+	assert.Equal(t, [][]int64{{1, 3, 2}, {1, 2}}, paths)
+}
+
+func TestKShortestPathsParallelShortcutUpdated(t *testing.T) {
+	// Initial edge 1 -> 2 becomes cheaper than parallel shortcut 1 -> 2 (via 3)
+	g := parallelShortcutGraph(t)
+	if !assert.NoError(t, g.UpdateEdgeWeight(1, 2, 0.5, true)) {
+		return
+	}
+	costs, paths := g.KShortestPaths(1, 4, 3)
+	assert.Equal(t, []float64{10.5, 12}, costs)
+	assert.Equal(t, [][]int64{{1, 2, 4}, {1, 3, 2, 4}}, paths)
+}
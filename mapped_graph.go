@@ -0,0 +1,194 @@
+package ch
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// MappedGraph Read-only contraction hierarchies opened from file prepared by ExportQueryGraph(w io.Writer).
+//
+// File is memory-mapped (where it is supported by platform), so opening is cheap and data is shared between processes via page cache.
+// Structure of file (offsets of arcs and bounds of IDs of vertices) is checked on opening, but checksum is not: call Verify() for untrusted files.
+//
+// MappedGraph is safe for concurrent use. Close() must not be called while queries are running.
+type MappedGraph struct {
+	data  []byte
+	unmap func() error
+	query *queryGraph
+	pool  *QueryPool
+}
+
+// OpenMappedGraph Opens file prepared by ExportQueryGraphToFile(fname string)
+func OpenMappedGraph(fname string) (*MappedGraph, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	// Mapping stays valid after file is closed
+	defer file.Close()
+
+	data, unmap, err := mmapFile(file)
+	if err != nil {
+		return nil, err
+	}
+	query, err := parseQueryGraph(data)
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+	return &MappedGraph{
+		data:  data,
+		unmap: unmap,
+		query: query,
+		pool:  newQueryPool(nil, query),
+	}, nil
+}
+
+// Close Releases mapped memory. Graph must not be used after this call.
+func (mapped *MappedGraph) Close() error {
+	if mapped.unmap == nil {
+		return nil
+	}
+	err := mapped.unmap()
+	mapped.unmap = nil
+	mapped.data = nil
+	mapped.query = nil
+	mapped.pool = nil
+	return err
+}
+
+// Verify Checks checksum of whole file
+func (mapped *MappedGraph) Verify() error {
+	body := mapped.data[:len(mapped.data)-4]
+	storedChecksum := binary.LittleEndian.Uint32(mapped.data[len(mapped.data)-4:])
+	if crc32.ChecksumIEEE(body) != storedChecksum {
+		return ErrBinaryChecksumMismatch
+	}
+	return nil
+}
+
+// GetVerticesNum Returns number of vertices in graph
+func (mapped *MappedGraph) GetVerticesNum() int64 {
+	return int64(mapped.query.verticesNum())
+}
+
+// NewQueryPool Creates a new QueryPool for concurrent query execution on mapped graph
+func (mapped *MappedGraph) NewQueryPool() *QueryPool {
+	return newQueryPool(nil, mapped.query)
+}
+
+// ShortestPath Computes and returns shortest path and it's cost (thread-safe). See QueryPool.ShortestPath(...)
+func (mapped *MappedGraph) ShortestPath(source, target int64) (float64, []int64) {
+	return mapped.pool.ShortestPath(source, target)
+}
+
+// ShortestPathOneToMany Computes and returns shortest paths and theirs costs for one-to-many relation (thread-safe). See QueryPool.ShortestPathOneToMany(...)
+func (mapped *MappedGraph) ShortestPathOneToMany(source int64, targets []int64) ([]float64, [][]int64) {
+	return mapped.pool.ShortestPathOneToMany(source, targets)
+}
+
+// ShortestPathManyToMany Computes and returns shortest paths and theirs costs for many-to-many relation (thread-safe). See QueryPool.ShortestPathManyToMany(...)
+func (mapped *MappedGraph) ShortestPathManyToMany(sources, targets []int64) ([][]float64, [][][]int64) {
+	return mapped.pool.ShortestPathManyToMany(sources, targets)
+}
+
+// parseQueryGraph Builds query graph on top of data prepared by ExportQueryGraph(w io.Writer). No data is copied if platform allows it.
+func parseQueryGraph(data []byte) (*queryGraph, error) {
+	if len(data) < queryGraphHeaderSize+4 {
+		return nil, errors.Wrap(ErrBinaryCorrupted, "File is too small")
+	}
+	if binary.LittleEndian.Uint32(data[0:4]) != queryGraphMagic {
+		return nil, ErrBinaryBadMagic
+	}
+	if version := binary.LittleEndian.Uint16(data[4:6]); version != queryGraphVersion {
+		return nil, errors.Wrapf(ErrBinaryUnsupportedVersion, "Version: %d", version)
+	}
+	verticesNum := binary.LittleEndian.Uint64(data[8:16])
+	var arcsNum [directionsCount]uint64
+	arcsNum[forward] = binary.LittleEndian.Uint64(data[16:24])
+	arcsNum[backward] = binary.LittleEndian.Uint64(data[24:32])
+
+	// Every number is 8 bytes long, so any of counts can't exceed size of file divided by 8 (protects from overflow below)
+	maxWords := uint64(len(data)) / 8
+	if verticesNum > maxWords || arcsNum[forward] > maxWords || arcsNum[backward] > maxWords {
+		return nil, errors.Wrap(ErrBinaryCorrupted, "Counts of vertices or arcs exceed file size")
+	}
+	words := 3*verticesNum + 2*(verticesNum+1) + 3*(arcsNum[forward]+arcsNum[backward])
+	if expected := uint64(queryGraphHeaderSize) + 8*words + 4; expected != uint64(len(data)) {
+		return nil, errors.Wrapf(ErrBinaryCorrupted, "Expected %d bytes, but file has %d bytes", expected, len(data))
+	}
+
+	offset := queryGraphHeaderSize
+	section := func(count uint64) []byte {
+		b := data[offset : offset+int(8*count)]
+		offset += int(8 * count)
+		return b
+	}
+	q := &queryGraph{}
+	q.labels = int64sView(section(verticesNum))
+	q.sortedLabels = int64sView(section(verticesNum))
+	q.sortedIDs = int64sView(section(verticesNum))
+	for d := forward; d < directionsCount; d++ {
+		q.offsets[d] = int64sView(section(verticesNum + 1))
+		q.targets[d] = int64sView(section(arcsNum[d]))
+		q.weights[d] = float64sView(section(arcsNum[d]))
+		q.via[d] = int64sView(section(arcsNum[d]))
+	}
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// validate Checks that queries can't go out of bounds of flat representation: offsets of arcs are monotonic,
+// IDs of vertices (targets of arcs, Via-vertices and sorted IDs) are in range [0; number of vertices)
+func (q *queryGraph) validate() error {
+	n := int64(q.verticesNum())
+	for _, id := range q.sortedIDs {
+		if id < 0 || id >= n {
+			return errors.Wrapf(ErrBinaryCorrupted, "Vertex ID %d is out of range", id)
+		}
+	}
+	for d := forward; d < directionsCount; d++ {
+		arcsNum := int64(len(q.targets[d]))
+		if q.offsets[d][0] != 0 || q.offsets[d][n] != arcsNum {
+			return errors.Wrap(ErrBinaryCorrupted, "Bad offsets of arcs")
+		}
+		for v := int64(0); v < n; v++ {
+			if q.offsets[d][v] > q.offsets[d][v+1] {
+				return errors.Wrapf(ErrBinaryCorrupted, "Offsets of arcs of vertex %d are not monotonic", v)
+			}
+		}
+		for e := int64(0); e < arcsNum; e++ {
+			if target := q.targets[d][e]; target < 0 || target >= n {
+				return errors.Wrapf(ErrBinaryCorrupted, "Target %d of arc is out of range", target)
+			}
+			if via := q.via[d][e]; via < -1 || via >= n {
+				return errors.Wrapf(ErrBinaryCorrupted, "Via-vertex %d of arc is out of range", via)
+			}
+		}
+	}
+	return nil
+}
+
+// decodeInt64s Decodes little-endian encoded data as slice of int64
+func decodeInt64s(b []byte) []int64 {
+	result := make([]int64, len(b)/8)
+	for i := range result {
+		result[i] = int64(binary.LittleEndian.Uint64(b[8*i:]))
+	}
+	return result
+}
+
+// decodeFloat64s Decodes little-endian encoded data as slice of float64
+func decodeFloat64s(b []byte) []float64 {
+	result := make([]float64, len(b)/8)
+	for i := range result {
+		result[i] = math.Float64frombits(binary.LittleEndian.Uint64(b[8*i:]))
+	}
+	return result
+}
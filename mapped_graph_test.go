@@ -0,0 +1,123 @@
+package ch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMappedGraph(t *testing.T) {
+	g, err := generateSyntheticGraph(32)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	dir, err := ioutil.TempDir("", "ch_mapped")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, "graph.chqg")
+	err = g.ExportQueryGraphToFile(fname)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	mapped, err := OpenMappedGraph(fname)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer mapped.Close()
+	assert.NoError(t, mapped.Verify())
+	assert.Equal(t, g.GetVerticesNum(), mapped.GetVerticesNum())
+
+	pool := g.NewQueryPool()
+	for i := range g.Vertices {
+		targets := make([]int64, 0, len(g.Vertices))
+		for j := range g.Vertices {
+			source, target := g.Vertices[i].Label, g.Vertices[j].Label
+			targets = append(targets, target)
+			expectedCost, expectedPath := g.ShortestPath(source, target)
+			for _, query := range []func(int64, int64) (float64, []int64){pool.ShortestPath, mapped.ShortestPath} {
+				cost, path := query(source, target)
+				if math.Abs(expectedCost-cost) > eps {
+					t.Errorf("Cost of path %d -> %d should be %f, but got %f", source, target, expectedCost, cost)
+					return
+				}
+				if len(expectedPath) != len(path) {
+					t.Errorf("Num of vertices in path %d -> %d should be %d, but got %d", source, target, len(expectedPath), len(path))
+					return
+				}
+			}
+		}
+		expectedCosts, _ := g.ShortestPathOneToMany(g.Vertices[i].Label, targets)
+		costs, paths := mapped.ShortestPathOneToMany(g.Vertices[i].Label, targets)
+		assert.Len(t, paths, len(targets))
+		assert.InDeltaSlice(t, expectedCosts, costs, eps)
+	}
+
+	cost, path := mapped.ShortestPath(-100, g.Vertices[0].Label)
+	assert.Equal(t, -1.0, cost)
+	assert.Nil(t, path)
+}
+
+func TestMappedGraphCorrupted(t *testing.T) {
+	g, err := generateSyntheticGraph(8)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var buf bytes.Buffer
+	err = g.ExportQueryGraph(&buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	data := buf.Bytes()
+
+	_, err = parseQueryGraph(data[:len(data)-8])
+	assert.Error(t, err)
+
+	damaged := make([]byte, len(data))
+	copy(damaged, data)
+	damaged[0] = 'X'
+	_, err = parseQueryGraph(damaged)
+	assert.Equal(t, ErrBinaryBadMagic, err)
+
+	copy(damaged, data)
+	damaged[len(damaged)-5] ^= 0xFF
+	mapped := &MappedGraph{data: damaged}
+	assert.Equal(t, ErrBinaryChecksumMismatch, mapped.Verify())
+
+	// Damaged sections of forward arcs: offset of the second vertex, target and Via-vertex of the first arc
+	verticesNum := int(binary.LittleEndian.Uint64(data[8:16]))
+	arcsNum := int(binary.LittleEndian.Uint64(data[16:24]))
+	offsetsPos := queryGraphHeaderSize + 3*8*verticesNum
+	targetsPos := offsetsPos + 8*(verticesNum+1)
+	viaPos := targetsPos + 2*8*arcsNum
+	for _, damage := range []struct {
+		pos   int
+		value int64
+	}{
+		{pos: offsetsPos + 8, value: int64(arcsNum + 1)},
+		{pos: targetsPos, value: int64(verticesNum)},
+		{pos: targetsPos, value: -1},
+		{pos: viaPos, value: int64(verticesNum)},
+		{pos: viaPos, value: -2},
+	} {
+		copy(damaged, data)
+		binary.LittleEndian.PutUint64(damaged[damage.pos:], uint64(damage.value))
+		_, err = parseQueryGraph(damaged)
+		assert.Equal(t, ErrBinaryCorrupted, errors.Cause(err))
+	}
+}
@@ -0,0 +1,32 @@
+//go:build amd64 || arm64 || ppc64le || mips64le || riscv64 || loong64
+// +build amd64 arm64 ppc64le mips64le riscv64 loong64
+
+package ch
+
+import (
+	"unsafe"
+)
+
+// int64sView Returns little-endian encoded data as slice of int64 without copying (if data is properly aligned)
+func int64sView(b []byte) []int64 {
+	if len(b) == 0 {
+		return nil
+	}
+	if uintptr(unsafe.Pointer(&b[0]))%8 != 0 {
+		return decodeInt64s(b)
+	}
+	n := len(b) / 8
+	return (*[1 << 40]int64)(unsafe.Pointer(&b[0]))[:n:n]
+}
+
+// float64sView Returns little-endian encoded data as slice of float64 without copying (if data is properly aligned)
+func float64sView(b []byte) []float64 {
+	if len(b) == 0 {
+		return nil
+	}
+	if uintptr(unsafe.Pointer(&b[0]))%8 != 0 {
+		return decodeFloat64s(b)
+	}
+	n := len(b) / 8
+	return (*[1 << 40]float64)(unsafe.Pointer(&b[0]))[:n:n]
+}
@@ -0,0 +1,14 @@
+//go:build !amd64 && !arm64 && !ppc64le && !mips64le && !riscv64 && !loong64
+// +build !amd64,!arm64,!ppc64le,!mips64le,!riscv64,!loong64
+
+package ch
+
+// int64sView Returns decoded copy of little-endian encoded data (zero-copy view is not supported on current architecture)
+func int64sView(b []byte) []int64 {
+	return decodeInt64s(b)
+}
+
+// float64sView Returns decoded copy of little-endian encoded data (zero-copy view is not supported on current architecture)
+func float64sView(b []byte) []float64 {
+	return decodeFloat64s(b)
+}
@@ -0,0 +1,235 @@
+package ch
+
+import (
+	"sort"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// graphMetric Named weights of initial edges and customization of hierarchies for them
+type graphMetric struct {
+	// Weights of initial edges indexed by customizableHierarchy.edgeOffsets()
+	weights []float64
+	// Current snapshot (*MetricSnapshot) with costs of this metric (replaced atomically on every customization)
+	snapshot atomic.Value
+	// Pool for ShortestPathMetric(...) and ShortestPathOneToManyMetric(...)
+	pool *QueryPool
+}
+
+// loadSnapshot Returns current snapshot of metric (nil if metric has not been customized yet)
+func (metric *graphMetric) loadSnapshot() *MetricSnapshot {
+	snapshot, _ := metric.snapshot.Load().(*MetricSnapshot)
+	return snapshot
+}
+
+// AddMetric Adds named metric: alternative weights of all edges which share topology and contraction order with graph.
+// Weights of graph itself (used by ShortestPath(...) and so on) are not changed.
+//
+// name - Name of metric
+// weight - Function which returns weight of edge in the metric. It is called for every edge with user's defined IDs of its vertices and current weight of edge.
+//
+// Only costs of arcs of hierarchies are stored for every metric: topology, shortcuts index and mapping of vertices are shared.
+// Metrics need customizable hierarchies: graph must be prepared by PrepareCustomizable() (then metrics are exact).
+// Graph prepared by PrepareContractionHierarchies() could be switched to triangle hierarchy explicitly by RecustomizeTriangles(),
+// but witness paths could vanish for new metric then and some queries could return longer paths.
+//
+// Returns error if CH has not been prepared yet, if hierarchies are not customizable or if metric with given name exists already.
+func (graph *Graph) AddMetric(name string, weight func(from, to int64, weight float64) float64) error {
+	if !graph.chPrepared {
+		return ErrCHNotPrepared
+	}
+	if graph.cch == nil {
+		return ErrCCHNotPrepared
+	}
+	graph.metricsMu.Lock()
+	defer graph.metricsMu.Unlock()
+	if _, ok := graph.metrics[name]; ok {
+		return errors.Wrapf(ErrMetricExists, "Metric '%s'", name)
+	}
+	offsets := graph.cch.edgeOffsets()
+	metric := &graphMetric{
+		weights: make([]float64, offsets[len(offsets)-1]),
+	}
+	for v := range graph.Vertices {
+		for j, edge := range graph.Vertices[v].outIncidentEdges[:graph.cch.originalOutDegree[v]] {
+			metric.weights[offsets[v]+int64(j)] = weight(graph.Vertices[v].Label, graph.Vertices[edge.vertexID].Label, edge.weight)
+		}
+	}
+	metric.pool = newMetricQueryPool(metric)
+	graph.customizeMetric(metric)
+	if graph.metrics == nil {
+		graph.metrics = make(map[string]*graphMetric)
+	}
+	graph.metrics[name] = metric
+	return nil
+}
+
+// RemoveMetric Removes named metric. Query pools created by NewMetricQueryPool(...) for it keep working with its last customization.
+//
+// Returns error if there is no metric with given name.
+func (graph *Graph) RemoveMetric(name string) error {
+	graph.metricsMu.Lock()
+	defer graph.metricsMu.Unlock()
+	if _, ok := graph.metrics[name]; !ok {
+		return errors.Wrapf(ErrMetricNotFound, "Metric '%s'", name)
+	}
+	delete(graph.metrics, name)
+	return nil
+}
+
+// Metrics Returns sorted names of all metrics added to graph
+func (graph *Graph) Metrics() []string {
+	graph.metricsMu.RLock()
+	defer graph.metricsMu.RUnlock()
+	names := make([]string, 0, len(graph.metrics))
+	for name := range graph.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UpdateMetricEdgeWeights Updates weights of multiple existing edges in named metric and recustomizes metric once.
+// Validation is the same as in UpdateEdgeWeights(...): if any update can't be applied then *BatchUpdateError is returned and metric is left unchanged.
+//
+// Returns error if there is no metric with given name.
+func (graph *Graph) UpdateMetricEdgeWeights(name string, updates []EdgeWeightUpdate) error {
+	graph.metricsMu.Lock()
+	defer graph.metricsMu.Unlock()
+	metric, err := graph.lookupMetric(name)
+	if err != nil {
+		return err
+	}
+	positions := make([]int64, 0, len(updates))
+	var invalid []error
+	for i, update := range updates {
+		edge, err := graph.internalEdge(EdgeKey{From: update.From, To: update.To})
+		if err != nil {
+			invalid = append(invalid, errors.Wrapf(err, "Update #%d", i))
+			continue
+		}
+		// Incident edges of shortcuts are placed after initial ones
+		j := graph.Vertices[edge.From].findOutIncidentEdge(edge.To)
+		if j >= graph.cch.originalOutDegree[edge.From] {
+			invalid = append(invalid, errors.Wrapf(ErrEdgeNotFound, "Update #%d: Edge %d -> %d", i, update.From, update.To))
+			continue
+		}
+		positions = append(positions, graph.cch.edgeOffsets()[edge.From]+int64(j))
+	}
+	if len(invalid) != 0 {
+		return &BatchUpdateError{Errors: invalid}
+	}
+
+	for i, position := range positions {
+		metric.weights[position] = updates[i].Weight
+	}
+	graph.customizeMetric(metric)
+	return nil
+}
+
+// NewMetricQueryPool Creates QueryPool which runs queries with costs of named metric. See NewQueryPool()
+//
+// Pool switches atomically to new snapshots of metric published by UpdateMetricEdgeWeights(...).
+//
+// Returns error if there is no metric with given name.
+func (graph *Graph) NewMetricQueryPool(name string) (*QueryPool, error) {
+	metric, err := graph.metric(name)
+	if err != nil {
+		return nil, err
+	}
+	return newMetricQueryPool(metric), nil
+}
+
+// ShortestPathMetric Computes and returns shortest path and it's cost (extended Dijkstra's algorithm) with costs of named metric. See ShortestPath(...)
+//
+// Returns error if there is no metric with given name.
+func (graph *Graph) ShortestPathMetric(name string, source, target int64) (float64, []int64, error) {
+	metric, err := graph.metric(name)
+	if err != nil {
+		return -1.0, nil, err
+	}
+	cost, path := metric.pool.ShortestPath(source, target)
+	return cost, path, nil
+}
+
+// ShortestPathOneToManyMetric Computes and returns shortest paths and theirs's costs with costs of named metric. See ShortestPathOneToMany(...)
+//
+// Returns error if there is no metric with given name.
+func (graph *Graph) ShortestPathOneToManyMetric(name string, source int64, targets []int64) ([]float64, [][]int64, error) {
+	metric, err := graph.metric(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	costs, paths := metric.pool.ShortestPathOneToMany(source, targets)
+	return costs, paths, nil
+}
+
+// metric Returns metric by its name
+func (graph *Graph) metric(name string) (*graphMetric, error) {
+	graph.metricsMu.RLock()
+	defer graph.metricsMu.RUnlock()
+	return graph.lookupMetric(name)
+}
+
+// lookupMetric Returns metric by its name. Must be called with metricsMu held.
+func (graph *Graph) lookupMetric(name string) (*graphMetric, error) {
+	metric, ok := graph.metrics[name]
+	if !ok {
+		return nil, errors.Wrapf(ErrMetricNotFound, "Metric '%s'", name)
+	}
+	return metric, nil
+}
+
+// customizeMetric Computes costs of arcs of hierarchies for metric and publishes them for queries
+//
+// Costs are stored in fresh arrays of new snapshot, so queries which are running with previous customization are not affected.
+// Flat representation shares offsets and targets with customizableHierarchy: arcs are stored as forward arcs of lower vertex (cchUp)
+// and as backward arcs of the same lower vertex (cchDown).
+func (graph *Graph) customizeMetric(metric *graphMetric) {
+	cch := graph.cch
+	var cost [2][]float64
+	var via [2][]int64
+	for d := range cost {
+		cost[d] = make([]float64, len(cch.head))
+		via[d] = make([]int64, len(cch.head))
+	}
+	graph.customizeCosts(cost, via, metric.weights)
+
+	q := &queryGraph{
+		labels:  graph.metricLabels(),
+		mapping: graph.mapping,
+	}
+	for d := forward; d < directionsCount; d++ {
+		q.offsets[d] = cch.firstArc
+		q.targets[d] = cch.head
+	}
+	q.weights[forward], q.via[forward] = cost[cchUp], via[cchUp]
+	q.weights[backward], q.via[backward] = cost[cchDown], via[cchDown]
+
+	version := uint64(1)
+	if previous := metric.loadSnapshot(); previous != nil {
+		version = previous.version + 1
+	}
+	metric.snapshot.Store(&MetricSnapshot{version: version, query: q})
+}
+
+// metricLabels Returns user's defined IDs of vertices indexed by library defined ones (shared by flat representations of all metrics). Must be called with metricsMu held.
+func (graph *Graph) metricLabels() []int64 {
+	for _, metric := range graph.metrics {
+		if snapshot := metric.loadSnapshot(); snapshot != nil {
+			return snapshot.query.labels
+		}
+	}
+	labels := make([]int64, len(graph.Vertices))
+	for i := range graph.Vertices {
+		labels[i] = graph.Vertices[i].Label
+	}
+	return labels
+}
+
+func newMetricQueryPool(metric *graphMetric) *QueryPool {
+	qp := newQueryPool(nil, nil)
+	qp.metric = metric
+	return qp
+}
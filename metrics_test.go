@@ -0,0 +1,199 @@
+package ch
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func distanceMetric(from, to int64, weight float64) float64 {
+	return float64(1 + (from*7+to*3)%9)
+}
+
+func TestMetrics(t *testing.T) {
+	const size = 15
+	g := generateGridGraph(size)
+	err := g.PrepareCustomizable()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = g.AddMetric("distance", distanceMetric)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = g.AddMetric("time", func(from, to int64, weight float64) float64 { return weight })
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, []string{"distance", "time"}, g.Metrics())
+
+	// The same graph with weights of metric
+	expected := generateGridGraph(size)
+	applyMetric(t, expected, distanceMetric)
+	checkMetric(t, g, expected, "distance", size*size)
+	if t.Failed() {
+		return
+	}
+	// Default weights are not changed
+	checkMetric(t, g, g, "time", size*size)
+	if t.Failed() {
+		return
+	}
+
+	pool, err := g.NewMetricQueryPool("distance")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	updates := []EdgeWeightUpdate{
+		{From: 0, To: 1, Weight: 20},
+		{From: 16, To: 17, Weight: 0.5},
+		{From: 112, To: 127, Weight: 9},
+	}
+	err = g.UpdateMetricEdgeWeights("distance", updates)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, update := range updates {
+		assert.NoError(t, expected.UpdateEdgeWeight(update.From, update.To, update.Weight, false))
+	}
+	checkMetric(t, g, expected, "distance", size*size)
+	expectedCost, _ := expected.VanillaShortestPath(0, 17)
+	cost, _ := pool.ShortestPath(0, 17)
+	assert.InDelta(t, expectedCost, cost, eps)
+	from, _ := g.FindVertex(0)
+	to, _ := g.FindVertex(1)
+	assert.Equal(t, 1.0, g.getEdgeCost(from, to))
+
+	assert.NoError(t, g.RemoveMetric("time"))
+	assert.Equal(t, []string{"distance"}, g.Metrics())
+}
+
+func TestMetricsInvalid(t *testing.T) {
+	g := generateGridGraph(3)
+	err := g.AddMetric("distance", distanceMetric)
+	assert.Equal(t, ErrCHNotPrepared, err)
+
+	g.PrepareContractionHierarchies()
+	// Hierarchies prepared with witness searches are not switched to triangle hierarchy implicitly
+	err = g.AddMetric("time", func(from, to int64, weight float64) float64 { return weight })
+	assert.Equal(t, ErrCCHNotPrepared, err)
+	assert.Nil(t, g.cch)
+	_, err = g.RecustomizeTriangles()
+	assert.NoError(t, err)
+	// Hierarchies prepared with witness searches are exact for the same weights
+	err = g.AddMetric("time", func(from, to int64, weight float64) float64 { return weight })
+	assert.NoError(t, err)
+	checkMetric(t, g, g, "time", 9)
+	err = g.AddMetric("time", distanceMetric)
+	assert.Equal(t, ErrMetricExists, errors.Cause(err))
+
+	_, _, err = g.ShortestPathMetric("toll", 0, 8)
+	assert.Equal(t, ErrMetricNotFound, errors.Cause(err))
+	_, _, err = g.ShortestPathOneToManyMetric("toll", 0, []int64{8})
+	assert.Equal(t, ErrMetricNotFound, errors.Cause(err))
+	_, err = g.NewMetricQueryPool("toll")
+	assert.Equal(t, ErrMetricNotFound, errors.Cause(err))
+	assert.Equal(t, ErrMetricNotFound, errors.Cause(g.RemoveMetric("toll")))
+
+	costBefore, _, _ := g.ShortestPathMetric("time", 0, 8)
+	err = g.UpdateMetricEdgeWeights("time", []EdgeWeightUpdate{
+		{From: 0, To: 1, Weight: 100},
+		{From: 0, To: 8, Weight: 1},
+	})
+	batchErr, ok := err.(*BatchUpdateError)
+	if assert.True(t, ok) && assert.Len(t, batchErr.Errors, 1) {
+		assert.Equal(t, ErrEdgeNotFound, errors.Cause(batchErr.Errors[0]))
+	}
+	cost, _, _ := g.ShortestPathMetric("time", 0, 8)
+	assert.Equal(t, costBefore, cost)
+}
+
+// applyMetric Replaces weights of all edges of graph (not prepared yet) by given metric
+func applyMetric(t *testing.T, g *Graph, weight func(from, to int64, weight float64) float64) {
+	for v := range g.Vertices {
+		from := g.Vertices[v].Label
+		for _, edge := range g.Vertices[v].outIncidentEdges {
+			to := g.Vertices[edge.vertexID].Label
+			assert.NoError(t, g.UpdateEdgeWeight(from, to, weight(from, to, edge.weight), false))
+		}
+	}
+}
+
+// checkMetric Compares queries with named metric against Dijkstra's algorithm on expected graph
+func checkMetric(t *testing.T, g, expected *Graph, name string, verticesNum int64) {
+	for source := int64(0); source < verticesNum; source += 23 {
+		targets := make([]int64, 0)
+		for target := int64(0); target < verticesNum; target += 4 {
+			targets = append(targets, target)
+		}
+		costs, paths, err := g.ShortestPathOneToManyMetric(name, source, targets)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		for i, target := range targets {
+			expectedCost, _ := expected.VanillaShortestPath(source, target)
+			cost, path, err := g.ShortestPathMetric(name, source, target)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if math.Abs(expectedCost-cost) > eps || math.Abs(expectedCost-costs[i]) > eps {
+				t.Errorf("Cost of path %d -> %d should be %f, but got %f (one to many: %f)", source, target, expectedCost, cost, costs[i])
+				return
+			}
+			assert.Equal(t, path, paths[i])
+			// Unpacked path should consist of edges of expected graph
+			pathCost := 0.0
+			for j := 1; j < len(path); j++ {
+				from, _ := expected.FindVertex(path[j-1])
+				to, _ := expected.FindVertex(path[j])
+				pathCost += expected.getEdgeCost(from, to)
+			}
+			if math.Abs(expectedCost-pathCost) > eps {
+				t.Errorf("Path %d -> %d should have cost %f, but got %f", source, target, expectedCost, pathCost)
+				return
+			}
+		}
+	}
+}
+
+func TestMetricsConcurrent(t *testing.T) {
+	g := generateGridGraph(5)
+	if !assert.NoError(t, g.PrepareCustomizable()) {
+		return
+	}
+	assert.NoError(t, g.AddMetric("distance", distanceMetric))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		name := fmt.Sprintf("metric%d", i)
+		go func() {
+			defer wg.Done()
+			for round := 0; round < 10; round++ {
+				assert.NoError(t, g.AddMetric(name, distanceMetric))
+				assert.NoError(t, g.UpdateMetricEdgeWeights(name, []EdgeWeightUpdate{{From: 0, To: 1, Weight: float64(round + 1)}}))
+				assert.NoError(t, g.RemoveMetric(name))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for round := 0; round < 10; round++ {
+				g.Metrics()
+				_, _, err := g.ShortestPathMetric("distance", 0, 24)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, []string{"distance"}, g.Metrics())
+}
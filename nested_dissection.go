@@ -0,0 +1,242 @@
+package ch
+
+import (
+	"sort"
+)
+
+const (
+	// dissectionLeafSize Parts which are not greater than this size are not dissected any further
+	dissectionLeafSize = 16
+	// dissectionBalance Separator level is considered as balanced one if both sides contain at least 1/dissectionBalance of part's vertices
+	dissectionBalance = 5
+)
+
+// NestedDissectionOrder Returns contraction order (user's defined IDs of vertices, the first one should be contracted first) computed by nested dissection.
+//
+// Graph is recursively split by small vertex separators (found via BFS level structures from pseudo-peripheral vertices): parts are contracted first, separators last.
+// Such order is metric-independent and produces small number of shortcuts without witness searches, so it is used by PrepareCustomizable().
+// It could be passed to PrepareWithOrder(...) as well.
+func (graph *Graph) NestedDissectionOrder() []int64 {
+	order := graph.nestedDissectionOrder()
+	for i := range order {
+		order[i] = graph.Vertices[order[i]].Label
+	}
+	return order
+}
+
+// dissectionPart Vertices of the part and position which the first contracted vertex of the part gets in order
+type dissectionPart struct {
+	vertices []int64
+	start    int
+}
+
+// nestedDissectionOrder Returns contraction order (library defined IDs of vertices) computed by nested dissection
+func (graph *Graph) nestedDissectionOrder() []int64 {
+	n := len(graph.Vertices)
+	adjacency := graph.undirectedAdjacency()
+	order := make([]int64, n)
+
+	// Buffers for BFS restricted to current part: vertex belongs to part if mark[v] == stamp
+	mark := make([]int64, n)
+	level := make([]int, n)
+	stamp := int64(0)
+
+	all := make([]int64, n)
+	for i := range all {
+		all[i] = int64(i)
+	}
+	stack := []dissectionPart{{vertices: all, start: 0}}
+	for len(stack) != 0 {
+		part := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if len(part.vertices) <= dissectionLeafSize {
+			orderByDegree(adjacency, part.vertices, order[part.start:])
+			continue
+		}
+
+		stamp++
+		for _, v := range part.vertices {
+			mark[v] = stamp
+		}
+		// Disconnected part is split into components without separator
+		components := connectedComponents(adjacency, part.vertices, mark, stamp)
+		if len(components) > 1 {
+			start := part.start
+			for _, component := range components {
+				stack = append(stack, dissectionPart{vertices: component, start: start})
+				start += len(component)
+			}
+			continue
+		}
+
+		first, separator, second := findSeparator(adjacency, part.vertices, mark, stamp, level)
+		if separator == nil {
+			// There is no reasonable separator (e.g. part is almost complete graph)
+			orderByDegree(adjacency, part.vertices, order[part.start:])
+			continue
+		}
+		// Separator is contracted after both sides
+		copy(order[part.start+len(first)+len(second):], separator)
+		stack = append(stack, dissectionPart{vertices: first, start: part.start})
+		stack = append(stack, dissectionPart{vertices: second, start: part.start + len(first)})
+	}
+	return order
+}
+
+// orderByDegree Puts vertices of part which is not dissected into order: in order of ascending degree
+func orderByDegree(adjacency [][]int64, vertices []int64, order []int64) {
+	sort.SliceStable(vertices, func(i, j int) bool {
+		return len(adjacency[vertices[i]]) < len(adjacency[vertices[j]])
+	})
+	copy(order, vertices)
+}
+
+// undirectedAdjacency Returns neighbors of every vertex (both incoming and outcoming, without duplicates and loops)
+func (graph *Graph) undirectedAdjacency() [][]int64 {
+	adjacency := make([][]int64, len(graph.Vertices))
+	for i := range graph.Vertices {
+		vertex := &graph.Vertices[i]
+		neighbors := make([]int64, 0, len(vertex.inIncidentEdges)+len(vertex.outIncidentEdges))
+		for _, edge := range vertex.inIncidentEdges {
+			neighbors = append(neighbors, edge.vertexID)
+		}
+		for _, edge := range vertex.outIncidentEdges {
+			neighbors = append(neighbors, edge.vertexID)
+		}
+		sort.Slice(neighbors, func(a, b int) bool { return neighbors[a] < neighbors[b] })
+		unique := neighbors[:0]
+		for k, v := range neighbors {
+			if v == int64(i) || (k > 0 && v == neighbors[k-1]) {
+				continue
+			}
+			unique = append(unique, v)
+		}
+		adjacency[i] = unique
+	}
+	return adjacency
+}
+
+// connectedComponents Returns connected components of part (vertex belongs to part if mark[v] == stamp).
+// Marks of visited vertices are changed to -stamp.
+func connectedComponents(adjacency [][]int64, vertices []int64, mark []int64, stamp int64) [][]int64 {
+	components := [][]int64{}
+	for _, v := range vertices {
+		if mark[v] != stamp {
+			continue
+		}
+		mark[v] = -stamp
+		component := []int64{v}
+		for head := 0; head < len(component); head++ {
+			for _, u := range adjacency[component[head]] {
+				if mark[u] == stamp {
+					mark[u] = -stamp
+					component = append(component, u)
+				}
+			}
+		}
+		components = append(components, component)
+	}
+	// Restore marks
+	for _, v := range vertices {
+		mark[v] = stamp
+	}
+	return components
+}
+
+// bfsLevels Computes BFS levels from source inside of connected part. Returns vertices in BFS order.
+func bfsLevels(adjacency [][]int64, source int64, vertices []int64, mark []int64, stamp int64, level []int) []int64 {
+	for _, v := range vertices {
+		level[v] = -1
+	}
+	level[source] = 0
+	queue := make([]int64, 0, len(vertices))
+	queue = append(queue, source)
+	for head := 0; head < len(queue); head++ {
+		v := queue[head]
+		for _, u := range adjacency[v] {
+			if mark[u] == stamp && level[u] < 0 {
+				level[u] = level[v] + 1
+				queue = append(queue, u)
+			}
+		}
+	}
+	return queue
+}
+
+// findSeparator Splits connected part into two sides and vertex separator between them (there are no edges between sides).
+// Returns nil separator if there is no reasonable one.
+func findSeparator(adjacency [][]int64, vertices []int64, mark []int64, stamp int64, level []int) ([]int64, []int64, []int64) {
+	// Pseudo-peripheral vertex: repeat BFS from the farthest vertex while eccentricity grows
+	source := vertices[0]
+	queue := bfsLevels(adjacency, source, vertices, mark, stamp, level)
+	for i := 0; i < 3; i++ {
+		farthest := queue[len(queue)-1]
+		eccentricity := level[farthest]
+		candidate := bfsLevels(adjacency, farthest, vertices, mark, stamp, level)
+		if level[candidate[len(candidate)-1]] <= eccentricity {
+			// Restore levels of the best source
+			queue = bfsLevels(adjacency, source, vertices, mark, stamp, level)
+			break
+		}
+		source, queue = farthest, candidate
+	}
+	levelsNum := level[queue[len(queue)-1]] + 1
+	if levelsNum < 3 {
+		return nil, nil, nil
+	}
+	levelSize := make([]int, levelsNum)
+	for _, v := range queue {
+		levelSize[level[v]]++
+	}
+
+	// The smallest balanced level (or the median one if there is no balanced level)
+	n := len(vertices)
+	best := -1
+	median := -1
+	before := levelSize[0]
+	for l := 1; l < levelsNum-1; l++ {
+		after := n - before - levelSize[l]
+		if median < 0 && before+levelSize[l] >= n/2 {
+			median = l
+		}
+		if before*dissectionBalance >= n && after*dissectionBalance >= n {
+			if best < 0 || levelSize[l] < levelSize[best] {
+				best = l
+			}
+		}
+		before += levelSize[l]
+	}
+	if best < 0 {
+		best = median
+	}
+	if best < 0 {
+		best = levelsNum - 2
+	}
+
+	// Vertices of separator level which have no neighbors in next level could be moved to the first side
+	first := make([]int64, 0, n)
+	second := make([]int64, 0, n)
+	separator := make([]int64, 0, levelSize[best])
+	for _, v := range queue {
+		switch {
+		case level[v] < best:
+			first = append(first, v)
+		case level[v] > best:
+			second = append(second, v)
+		default:
+			needed := false
+			for _, u := range adjacency[v] {
+				if mark[u] == stamp && level[u] == best+1 {
+					needed = true
+					break
+				}
+			}
+			if needed {
+				separator = append(separator, v)
+			} else {
+				first = append(first, v)
+			}
+		}
+	}
+	return first, separator, second
+}
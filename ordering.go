@@ -0,0 +1,164 @@
+package ch
+
+// OrderingStrategy Computes importance of vertex during contraction hierarchies preparation.
+// Vertices with lower importance are contracted earlier. Importance is recomputed lazily: right before vertex is going to be contracted.
+//
+// Strategy is called from the goroutine which prepares contraction hierarchies (even in parallel mode), so it may keep internal buffers,
+// but the same strategy must not be used by several preparations simultaneously.
+type OrderingStrategy interface {
+	Importance(graph *Graph, vertex *Vertex) int
+}
+
+// ImportanceFunc Adapter to use ordinary function as OrderingStrategy
+type ImportanceFunc func(graph *Graph, vertex *Vertex) int
+
+// Importance Calls fn(graph, vertex)
+func (fn ImportanceFunc) Importance(graph *Graph, vertex *Vertex) int {
+	return fn(graph, vertex)
+}
+
+// WithOrdering Sets strategy for computing importance of vertices. See OrderingStrategy
+func WithOrdering(strategy OrderingStrategy) PrepareOption {
+	return func(options *prepareOptions) {
+		options.ordering = strategy
+	}
+}
+
+// updateImportance Recomputes importance of vertex by given strategy (nil means default one)
+func (graph *Graph) updateImportance(vertex *Vertex, ordering OrderingStrategy) {
+	if ordering == nil {
+		vertex.computeImportance()
+		return
+	}
+	vertex.importance = ordering.Importance(graph, vertex)
+}
+
+// DefaultOrdering Returns strategy which is used when no other one is provided:
+// edge difference (estimated as in-degree*out-degree minus degree) + degree + contracted neighbors - bidirected edges
+func DefaultOrdering() OrderingStrategy {
+	return ImportanceFunc(func(graph *Graph, vertex *Vertex) int {
+		return vertex.defaultImportance()
+	})
+}
+
+// EdgeDifferenceOrdering Returns strategy which computes edge difference by simulating contraction (with witness searches):
+// number of shortcuts which would be added minus number of incident edges which would be removed.
+//
+// It is much more precise than estimation of DefaultOrdering(), but every importance update costs a contraction simulation.
+func EdgeDifferenceOrdering() OrderingStrategy {
+	return &edgeDifferenceOrdering{}
+}
+
+type edgeDifferenceOrdering struct {
+	simulator contractionSimulator
+}
+
+func (ordering *edgeDifferenceOrdering) Importance(graph *Graph, vertex *Vertex) int {
+	shortcuts := ordering.simulator.simulate(graph, vertex)
+	removed := 0
+	graph.forEachActiveArc(vertex, func(from, to int64) {
+		removed++
+	})
+	return len(shortcuts) - removed
+}
+
+// OriginalEdgesOrdering Returns strategy which computes "hop" term by simulating contraction (with witness searches):
+// number of initial edges represented by shortcuts which would be added minus number of initial edges represented by incident edges which would be removed.
+//
+// It prevents long chains of nested shortcuts, so unpacking of paths is cheaper.
+func OriginalEdgesOrdering() OrderingStrategy {
+	return &originalEdgesOrdering{}
+}
+
+type originalEdgesOrdering struct {
+	simulator contractionSimulator
+}
+
+func (ordering *originalEdgesOrdering) Importance(graph *Graph, vertex *Vertex) int {
+	shortcuts := ordering.simulator.simulate(graph, vertex)
+	added := 0
+	for _, shortcut := range shortcuts {
+		added += graph.arcOriginalEdges(shortcut.From, shortcut.Via) + graph.arcOriginalEdges(shortcut.Via, shortcut.To)
+	}
+	removed := 0
+	graph.forEachActiveArc(vertex, func(from, to int64) {
+		removed += graph.arcOriginalEdges(from, to)
+	})
+	return added - removed
+}
+
+// LevelOrdering Returns strategy which uses level of vertex: upper bound of search space depth.
+// Level of vertex is increased when its neighbor is contracted: level(neighbor) = max(level(neighbor), level(vertex) + 1).
+//
+// It keeps hierarchies flat, so query search spaces are smaller.
+func LevelOrdering() OrderingStrategy {
+	return ImportanceFunc(func(graph *Graph, vertex *Vertex) int {
+		return vertex.level
+	})
+}
+
+// ContractedNeighborsOrdering Returns strategy which uses number of already contracted neighbors of vertex.
+//
+// It spreads contraction uniformly over graph.
+func ContractedNeighborsOrdering() OrderingStrategy {
+	return ImportanceFunc(func(graph *Graph, vertex *Vertex) int {
+		return vertex.delNeighbors
+	})
+}
+
+// OrderingTerm Strategy with weight. See CombinedOrdering(...)
+type OrderingTerm struct {
+	Strategy OrderingStrategy
+	Weight   int
+}
+
+// CombinedOrdering Returns strategy which computes weighted sum of importances given by other strategies.
+//
+// Example (edge difference is the most important term):
+//
+//	ch.CombinedOrdering(
+//		ch.OrderingTerm{Strategy: ch.EdgeDifferenceOrdering(), Weight: 2},
+//		ch.OrderingTerm{Strategy: ch.OriginalEdgesOrdering(), Weight: 1},
+//		ch.OrderingTerm{Strategy: ch.ContractedNeighborsOrdering(), Weight: 1},
+//		ch.OrderingTerm{Strategy: ch.LevelOrdering(), Weight: 1},
+//	)
+func CombinedOrdering(terms ...OrderingTerm) OrderingStrategy {
+	return ImportanceFunc(func(graph *Graph, vertex *Vertex) int {
+		importance := 0
+		for _, term := range terms {
+			importance += term.Weight * term.Strategy.Importance(graph, vertex)
+		}
+		return importance
+	})
+}
+
+// forEachActiveArc Calls fn for every incident edge of vertex which leads to (or comes from) not contracted vertex
+func (graph *Graph) forEachActiveArc(vertex *Vertex, fn func(from, to int64)) {
+	for _, edge := range vertex.inIncidentEdges {
+		if !graph.Vertices[edge.vertexID].contracted {
+			fn(edge.vertexID, vertex.vertexNum)
+		}
+	}
+	for _, edge := range vertex.outIncidentEdges {
+		if !graph.Vertices[edge.vertexID].contracted {
+			fn(vertex.vertexNum, edge.vertexID)
+		}
+	}
+}
+
+// contractionSimulator Finds shortcuts which would be added if vertex was contracted (graph is not modified)
+type contractionSimulator struct {
+	searcher *witnessSearcher
+}
+
+func (simulator *contractionSimulator) simulate(graph *Graph, vertex *Vertex) []ShortcutPath {
+	if simulator.searcher == nil || len(simulator.searcher.dist) != len(graph.Vertices) {
+		simulator.searcher = newWitnessSearcher(len(graph.Vertices))
+	}
+	// Exclude vertex from witness searches temporarily
+	contracted := vertex.contracted
+	vertex.contracted = true
+	shortcuts := simulator.searcher.findShortcuts(graph, vertex)
+	vertex.contracted = contracted
+	return shortcuts
+}
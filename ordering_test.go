@@ -0,0 +1,74 @@
+package ch
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderingStrategies(t *testing.T) {
+	strategies := map[string]OrderingStrategy{
+		"default":              DefaultOrdering(),
+		"edge_difference":      EdgeDifferenceOrdering(),
+		"original_edges":       OriginalEdgesOrdering(),
+		"level":                LevelOrdering(),
+		"contracted_neighbors": ContractedNeighborsOrdering(),
+		"combined": CombinedOrdering(
+			OrderingTerm{Strategy: EdgeDifferenceOrdering(), Weight: 2},
+			OrderingTerm{Strategy: OriginalEdgesOrdering(), Weight: 1},
+			OrderingTerm{Strategy: ContractedNeighborsOrdering(), Weight: 1},
+			OrderingTerm{Strategy: LevelOrdering(), Weight: 1},
+		),
+	}
+	const size = 20
+	for name, strategy := range strategies {
+		g := generateGridGraph(size)
+		g.PrepareContractionHierarchies(WithOrdering(strategy))
+		for _, source := range []int64{0, 17, 210, 399} {
+			for target := int64(0); target < size*size; target += 7 {
+				expectedCost, _ := g.VanillaShortestPath(source, target)
+				cost, path := g.ShortestPath(source, target)
+				if math.Abs(expectedCost-cost) > eps {
+					t.Errorf("Strategy %s. Cost of path %d -> %d should be %f, but got %f", name, source, target, expectedCost, cost)
+					return
+				}
+				if path[0] != source || path[len(path)-1] != target {
+					t.Errorf("Strategy %s. Path %d -> %d has wrong endpoints: %v", name, source, target, path)
+					return
+				}
+			}
+		}
+	}
+}
+
+func TestDefaultOrderingIsDefault(t *testing.T) {
+	expected := generateGridGraph(15)
+	expected.PrepareContractionHierarchies()
+	g := generateGridGraph(15)
+	g.PrepareContractionHierarchies(WithOrdering(DefaultOrdering()))
+	assert.Equal(t, expected.contractionOrder, g.contractionOrder)
+	assert.Equal(t, expected.GetShortcutsNum(), g.GetShortcutsNum())
+}
+
+func TestOriginalEdgesOfShortcuts(t *testing.T) {
+	g := generateGridGraph(15)
+	g.PrepareContractionHierarchies(WithOrdering(EdgeDifferenceOrdering()))
+	for from, shortcuts := range g.shortcuts {
+		for to, shortcut := range shortcuts {
+			// Number of initial edges is the length of unpacked path
+			path := g.unpackShortcut(from, to)
+			assert.Equal(t, len(path)-1, shortcut.originalEdges)
+		}
+	}
+}
+
+// unpackShortcut Returns internal IDs of vertices of path represented by arc
+func (graph *Graph) unpackShortcut(from, to int64) []int64 {
+	shortcut, ok := graph.shortcuts[from][to]
+	if !ok {
+		return []int64{from, to}
+	}
+	left := graph.unpackShortcut(from, shortcut.Via)
+	return append(left, graph.unpackShortcut(shortcut.Via, to)[1:]...)
+}
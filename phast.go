@@ -0,0 +1,132 @@
+package ch
+
+import (
+	"container/heap"
+)
+
+// ShortestPathsOneToAll Computes and returns costs of shortest paths from source vertex to every vertex of graph (PHAST)
+//
+// If there are some errors (or CH has not been prepared) then function returns nil.
+//
+// source - User's definied ID of source vertex
+//
+// Costs are indexed by library defined IDs of vertices, i.e. costs[i] is cost of path to graph.Vertices[i]. Cost of unreachable vertex is '-1.0'.
+// Upward search in contraction hierarchies from source is followed by linear sweep over downward arcs of all vertices in descending order of contraction (orderPos).
+// Function does not touch shared query state of graph, so it could be called concurrently (while graph is not modified).
+//
+// Reference: D. Delling, A. V. Goldberg, A. Nowatzyk, R. F. Werneck. PHAST: Hardware-Accelerated Shortest Path Trees. https://doi.org/10.1016/j.jpdc.2012.02.007
+func (graph *Graph) ShortestPathsOneToAll(source int64) []float64 {
+	costs, _ := graph.shortestPathsOneToAll(source, false)
+	return costs
+}
+
+// ShortestPathsOneToAllWithParents Computes and returns costs of shortest paths from source vertex to every vertex of graph and shortest path tree (PHAST)
+//
+// If there are some errors (or CH has not been prepared) then function returns nil costs and nil parents.
+//
+// source - User's definied ID of source vertex
+//
+// Costs and parents are indexed by library defined IDs of vertices (see ShortestPathsOneToAll(...)).
+// parents[i] is library defined ID of previous vertex on the shortest path to graph.Vertices[i] in terms of initial edges (shortcuts are unpacked), '-1' for source and unreachable vertices.
+// So path to vertex could be reconstructed by following parents up to source.
+func (graph *Graph) ShortestPathsOneToAllWithParents(source int64) ([]float64, []int64) {
+	return graph.shortestPathsOneToAll(source, true)
+}
+
+func (graph *Graph) shortestPathsOneToAll(source int64, withParents bool) ([]float64, []int64) {
+	if !graph.chPrepared {
+		return nil, nil
+	}
+	var ok bool
+	if source, ok = graph.mapping[source]; !ok {
+		return nil, nil
+	}
+	costs := make([]float64, len(graph.Vertices))
+	for i := range costs {
+		costs[i] = Infinity
+	}
+	var parents []int64
+	var shortcutParents []bool
+	if withParents {
+		parents = make([]int64, len(graph.Vertices))
+		for i := range parents {
+			parents[i] = -1
+		}
+		shortcutParents = make([]bool, len(graph.Vertices))
+	}
+	graph.phastUpward(source, costs, parents, shortcutParents)
+	for i := len(graph.contractionOrder) - 1; i >= 0; i-- {
+		graph.phastRelaxDownward(graph.contractionOrder[i], costs, parents, shortcutParents)
+	}
+	if withParents {
+		graph.unpackParents(parents, shortcutParents)
+	}
+	for i := range costs {
+		if costs[i] == Infinity {
+			costs[i] = -1.0
+		}
+	}
+	return costs, parents
+}
+
+// phastUpward Dijkstra's algorithm on upward arcs of contraction hierarchies from source vertex (library defined ID).
+// Costs must be filled by Infinity. Parents (could be nil) are ends of arcs (possibly shortcuts): shortcutParents[i] is set if arc parents[i] -> i is shortcut
+func (graph *Graph) phastUpward(source int64, costs []float64, parents []int64, shortcutParents []bool) {
+	costs[source] = 0
+	queue := &minHeap{}
+	queue.add_with_priority(source, 0)
+	for queue.Len() != 0 {
+		u := heap.Pop(queue).(*minHeapVertex)
+		if u.distance > costs[u.id] {
+			// Outdated item of queue
+			continue
+		}
+		for _, edge := range graph.Vertices[u.id].outIncidentEdges {
+			neighbor := edge.vertexID
+			if graph.Vertices[u.id].orderPos >= graph.Vertices[neighbor].orderPos {
+				continue
+			}
+			alt := u.distance + edge.weight
+			if alt < costs[neighbor] {
+				costs[neighbor] = alt
+				if parents != nil {
+					parents[neighbor] = u.id
+					shortcutParents[neighbor] = edge.shortcut
+				}
+				queue.add_with_priority(neighbor, alt)
+			}
+		}
+	}
+}
+
+// phastRelaxDownward Relaxes downward arcs which enter given vertex (library defined ID). Costs of vertices of higher orderPos must be final
+func (graph *Graph) phastRelaxDownward(vertex int64, costs []float64, parents []int64, shortcutParents []bool) {
+	for _, edge := range graph.Vertices[vertex].inIncidentEdges {
+		from := edge.vertexID
+		if graph.Vertices[from].orderPos <= graph.Vertices[vertex].orderPos {
+			continue
+		}
+		if alt := costs[from] + edge.weight; alt < costs[vertex] {
+			costs[vertex] = alt
+			if parents != nil {
+				parents[vertex] = from
+				shortcutParents[vertex] = edge.shortcut
+			}
+		}
+	}
+}
+
+// unpackParents Replaces parents which are starts of shortcuts by previous vertices in terms of initial edges.
+// Shortcut Via -> vertex is unpacked further only if it is cheaper than initial edge parallel to it
+func (graph *Graph) unpackParents(parents []int64, shortcutParents []bool) {
+	for vertex, parent := range parents {
+		if parent < 0 || !shortcutParents[vertex] {
+			continue
+		}
+		for via := graph.shortcuts[parent][int64(vertex)].Via; via >= 0; {
+			parent = via
+			_, via = graph.cheapestArc(parent, int64(vertex))
+		}
+		parents[vertex] = parent
+	}
+}
@@ -0,0 +1,92 @@
+package ch
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShortestPathsOneToAll(t *testing.T) {
+	const size = 8
+	g := generateGridGraph(size)
+	// Vertex without edges
+	g.CreateVertex(size * size)
+	g.PrepareContractionHierarchies()
+
+	for source := int64(0); source < size*size; source += 9 {
+		costs, parents := g.ShortestPathsOneToAllWithParents(source)
+		if !assert.Len(t, costs, len(g.Vertices)) || !assert.Len(t, parents, len(g.Vertices)) {
+			return
+		}
+		assert.Equal(t, costs, g.ShortestPathsOneToAll(source))
+		sourceInternal, _ := g.FindVertex(source)
+		for i := range g.Vertices {
+			expectedCost, _ := g.VanillaShortestPath(source, g.Vertices[i].Label)
+			if g.Vertices[i].Label == size*size {
+				expectedCost = -1
+			}
+			if math.Abs(expectedCost-costs[i]) > eps {
+				t.Errorf("Cost of path %d -> %d should be %f, but got %f", source, g.Vertices[i].Label, expectedCost, costs[i])
+				return
+			}
+			// Tree path consists of initial edges and has the same cost
+			pathCost := 0.0
+			for vertex := int64(i); parents[vertex] >= 0; vertex = parents[vertex] {
+				edgeCost := g.originalEdgeCost(parents[vertex], vertex)
+				if !assert.True(t, edgeCost >= 0, "There is no edge %d -> %d", parents[vertex], vertex) {
+					return
+				}
+				pathCost += edgeCost
+			}
+			if costs[i] >= 0 {
+				assert.InDelta(t, costs[i], pathCost, eps)
+			}
+		}
+		assert.Equal(t, int64(-1), parents[sourceInternal])
+	}
+
+	assert.Nil(t, g.ShortestPathsOneToAll(1000))
+	assert.Nil(t, generateGridGraph(3).ShortestPathsOneToAll(0))
+}
+
+func TestShortestPathsOneToAllCustomizable(t *testing.T) {
+	const size = 6
+	g := generateGridGraph(size)
+	if !assert.NoError(t, g.PrepareCustomizable()) {
+		return
+	}
+	for source := int64(0); source < size*size; source += 7 {
+		costs := g.ShortestPathsOneToAll(source)
+		for i := range g.Vertices {
+			expectedCost, _ := g.VanillaShortestPath(source, g.Vertices[i].Label)
+			if math.Abs(expectedCost-costs[i]) > eps {
+				t.Errorf("Cost of path %d -> %d should be %f, but got %f", source, g.Vertices[i].Label, expectedCost, costs[i])
+				return
+			}
+		}
+	}
+}
+
+func TestShortestPathsOneToAllParallelShortcut(t *testing.T) {
+	// Shortcut 1 -> 2 (via 3) is parallel to initial edge 1 -> 2
+	g := parallelShortcutGraph(t)
+	ids := make(map[int64]int64)
+	for _, label := range []int64{1, 2, 3, 4} {
+		ids[label], _ = g.FindVertex(label)
+	}
+	costs, parents := g.ShortestPathsOneToAllWithParents(1)
+	assert.Equal(t, 2.0, costs[ids[2]])
+	assert.Equal(t, ids[3], parents[ids[2]])
+	assert.Equal(t, ids[2], parents[ids[4]])
+
+	// Initial edge 1 -> 2 becomes cheaper than shortcut
+	if !assert.NoError(t, g.UpdateEdgeWeight(1, 2, 0.5, true)) {
+		return
+	}
+	costs, parents = g.ShortestPathsOneToAllWithParents(1)
+	assert.Equal(t, 0.5, costs[ids[2]])
+	assert.Equal(t, ids[1], parents[ids[2]])
+	assert.Equal(t, 10.5, costs[ids[4]])
+	assert.Equal(t, ids[2], parents[ids[4]])
+}
@@ -0,0 +1,135 @@
+package ch
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// progressInterval Number of contracted vertices between two progress reports
+	progressInterval = 1000
+)
+
+// PrepareProgress State of contraction hierarchies preparation. See WithProgress(...)
+//
+// Contracted - Number of contracted vertices
+// Remaining - Number of vertices remaining in importance heap
+// Shortcuts - Current number of shortcuts
+// Elapsed - Time since preparation has been started
+type PrepareProgress struct {
+	Contracted int64
+	Remaining  int
+	Shortcuts  int64
+	Elapsed    time.Duration
+}
+
+// WithProgress Sets callback which is called every 1000 contracted vertices and once the preparation is finished.
+// Callback is called from the goroutine which prepares contraction hierarchies.
+func WithProgress(fn func(progress PrepareProgress)) PrepareOption {
+	return func(options *prepareOptions) {
+		options.progress = fn
+	}
+}
+
+// PrepareContractionHierarchiesContext Compute contraction hierarchies. See PrepareContractionHierarchies(...)
+//
+// Returns error if CH has been prepared already or if context is cancelled (or its deadline is exceeded) before contraction is finished.
+// In the last case graph is left partially contracted: it can't be used for queries and should be rebuilt.
+func (graph *Graph) PrepareContractionHierarchiesContext(ctx context.Context, opts ...PrepareOption) error {
+	if graph.chPrepared {
+		return ErrCHAlreadyPrepared
+	}
+	options := newPrepareOptions(opts...)
+	monitor := newPrepareMonitor(ctx, options, 0)
+	if err := monitor.interrupted(); err != nil {
+		return err
+	}
+	pqImportance := graph.computeImportance(options.ordering)
+	// Preallocate contractionOrder slice
+	graph.contractionOrder = make([]int64, 0, len(graph.Vertices))
+	return graph.runPreprocess(pqImportance, options, monitor)
+}
+
+// runPreprocess Continues contraction from current state of graph with given options
+func (graph *Graph) runPreprocess(pqImportance *importanceHeap, options *prepareOptions, monitor *prepareMonitor) error {
+	var err error
+	if options.workers > 1 {
+		err = graph.preprocessParallel(pqImportance, options, monitor)
+	} else {
+		err = graph.preprocess(pqImportance, options, monitor)
+	}
+	if err != nil {
+		return err
+	}
+	graph.Freeze()
+	return nil
+}
+
+// prepareMonitor Tracks state of contraction hierarchies preparation: checks for cancellation, reports progress and saves checkpoints
+type prepareMonitor struct {
+	ctx            context.Context
+	progress       func(progress PrepareProgress)
+	checkpoint     func(checkpoint *Checkpoint) error
+	started        time.Time
+	nextReport     int64
+	nextCheckpoint int64
+	// checkpointEvery - Number of contracted vertices between two checkpoints
+	checkpointEvery int64
+}
+
+// newPrepareMonitor Creates monitor for preparation
+//
+// contractedNum - Number of vertices contracted before preparation has been (re)started
+func newPrepareMonitor(ctx context.Context, options *prepareOptions, contractedNum int64) *prepareMonitor {
+	monitor := &prepareMonitor{
+		ctx:             ctx,
+		progress:        options.progress,
+		checkpoint:      options.checkpoint,
+		checkpointEvery: int64(options.checkpointEvery),
+		started:         time.Now(),
+	}
+	monitor.nextReport = nextThreshold(contractedNum, progressInterval)
+	if monitor.checkpoint != nil {
+		monitor.nextCheckpoint = nextThreshold(contractedNum, monitor.checkpointEvery)
+	}
+	return monitor
+}
+
+// nextThreshold Returns the smallest multiple of interval which is greater than value
+func nextThreshold(value, interval int64) int64 {
+	return (value/interval + 1) * interval
+}
+
+// interrupted Returns error if preparation should be stopped
+func (monitor *prepareMonitor) interrupted() error {
+	select {
+	case <-monitor.ctx.Done():
+		return errors.Wrap(monitor.ctx.Err(), "Contraction hierarchies preparation has been interrupted")
+	default:
+		return nil
+	}
+}
+
+// contracted Should be called after every contraction step (when graph and heap are in consistent state)
+func (monitor *prepareMonitor) contracted(graph *Graph, pqImportance *importanceHeap) error {
+	contractedNum := int64(len(graph.contractionOrder))
+	remaining := pqImportance.Len()
+	if monitor.progress != nil && (contractedNum >= monitor.nextReport || remaining == 0) {
+		monitor.nextReport = nextThreshold(contractedNum, progressInterval)
+		monitor.progress(PrepareProgress{
+			Contracted: contractedNum,
+			Remaining:  remaining,
+			Shortcuts:  graph.shortcutsNum,
+			Elapsed:    time.Since(monitor.started),
+		})
+	}
+	if monitor.checkpoint != nil && contractedNum >= monitor.nextCheckpoint && remaining != 0 {
+		monitor.nextCheckpoint = nextThreshold(contractedNum, monitor.checkpointEvery)
+		if err := monitor.checkpoint(graph.makeCheckpoint(pqImportance)); err != nil {
+			return errors.Wrap(err, "Can't save checkpoint")
+		}
+	}
+	return monitor.interrupted()
+}
@@ -0,0 +1,61 @@
+package ch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrepareContext(t *testing.T) {
+	g, err := buildSyntheticGraph(32)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	reports := []PrepareProgress{}
+	err = g.PrepareContractionHierarchiesContext(context.Background(), WithProgress(func(progress PrepareProgress) {
+		reports = append(reports, progress)
+	}))
+	assert.NoError(t, err)
+	assert.True(t, g.chPrepared)
+	if assert.Len(t, reports, 1) {
+		assert.Equal(t, g.GetVerticesNum(), reports[0].Contracted)
+		assert.Equal(t, 0, reports[0].Remaining)
+		assert.Equal(t, g.GetShortcutsNum(), reports[0].Shortcuts)
+	}
+
+	// Hierarchies are not prepared twice
+	shortcutsNum := g.GetShortcutsNum()
+	contractionOrder := append([]int64(nil), g.contractionOrder...)
+	err = g.PrepareContractionHierarchiesContext(context.Background())
+	assert.Equal(t, ErrCHAlreadyPrepared, err)
+	g.PrepareContractionHierarchies()
+	assert.Equal(t, shortcutsNum, g.GetShortcutsNum())
+	assert.Equal(t, contractionOrder, g.contractionOrder)
+}
+
+func TestPrepareContextCancel(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		g := generateGridGraph(40)
+		ctx, cancel := context.WithCancel(context.Background())
+		reports := []PrepareProgress{}
+		err := g.PrepareContractionHierarchiesContext(ctx, WithWorkers(workers), WithProgress(func(progress PrepareProgress) {
+			reports = append(reports, progress)
+			cancel()
+		}))
+		assert.Equal(t, context.Canceled, errors.Cause(err))
+		assert.False(t, g.chPrepared)
+		if assert.Len(t, reports, 1) {
+			assert.True(t, reports[0].Contracted >= progressInterval)
+			assert.Equal(t, g.GetVerticesNum()-reports[0].Contracted, int64(reports[0].Remaining))
+		}
+	}
+
+	g := generateGridGraph(5)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := g.PrepareContractionHierarchiesContext(ctx)
+	assert.Equal(t, context.Canceled, errors.Cause(err))
+}
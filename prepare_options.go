@@ -0,0 +1,44 @@
+package ch
+
+// PrepareOption Optional parameter of contraction hierarchies preparation. See PrepareContractionHierarchies(...)
+type PrepareOption func(*prepareOptions)
+
+// prepareOptions Parameters of contraction hierarchies preparation
+//
+// workers - Number of goroutines for witness searches (1 means serial contraction)
+// progress - Callback for progress reports (could be nil)
+// checkpoint - Callback for saving checkpoints (could be nil)
+// checkpointEvery - Number of contracted vertices between two checkpoints
+// ordering - Strategy for computing importance of vertices (nil means default one)
+type prepareOptions struct {
+	ordering        OrderingStrategy
+	workers         int
+	progress        func(progress PrepareProgress)
+	checkpoint      func(checkpoint *Checkpoint) error
+	checkpointEvery int
+}
+
+// newPrepareOptions Returns parameters with defaults overridden by given options
+func newPrepareOptions(opts ...PrepareOption) *prepareOptions {
+	options := &prepareOptions{
+		workers: 1,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// WithWorkers Sets number of workers for parallel contraction.
+//
+// When workers > 1, independent sets of low-importance vertices (no two of them are adjacent) are contracted simultaneously:
+// witness searches run concurrently and found shortcuts are inserted in batches.
+// Resulting hierarchies are as correct as serial ones, but contraction order (and therefore number of shortcuts) may differ.
+func WithWorkers(workers int) PrepareOption {
+	return func(options *prepareOptions) {
+		if workers < 1 {
+			workers = 1
+		}
+		options.workers = workers
+	}
+}
@@ -0,0 +1,59 @@
+package ch
+
+import (
+	"github.com/pkg/errors"
+)
+
+// PrepareWithOrder Compute contraction hierarchies with given contraction order (e.g. computed by nested dissection or by another routing engine)
+//
+// order - User's defined IDs of vertices in the order of contraction. Every vertex of graph must be presented exactly once.
+//
+// Vertices are contracted exactly in that sequence with usual witness searches and shortcuts insertion,
+// so resulting graph supports queries and recustomization as well as graph prepared by PrepareContractionHierarchies().
+//
+// Returns error if CH has been prepared already or if order is invalid.
+func (graph *Graph) PrepareWithOrder(order []int64) error {
+	if graph.chPrepared {
+		return ErrCHAlreadyPrepared
+	}
+	internalOrder, err := graph.internalOrder(order)
+	if err != nil {
+		return err
+	}
+
+	graph.Freeze()
+	graph.contractionOrder = make([]int64, 0, len(graph.Vertices))
+	for i, vertexNum := range internalOrder {
+		vertex := &graph.Vertices[vertexNum]
+		vertex.orderPos = int64(i)
+		vertex.importance = i
+		graph.contractNode(vertex)
+		graph.contractionOrder = append(graph.contractionOrder, vertexNum)
+	}
+
+	// Mark CH as prepared
+	graph.chPrepared = true
+	graph.publishSnapshot()
+	return nil
+}
+
+// internalOrder Validates contraction order (user's defined IDs of vertices) and converts it to library defined IDs
+func (graph *Graph) internalOrder(order []int64) ([]int64, error) {
+	if len(order) != len(graph.Vertices) {
+		return nil, errors.Wrapf(ErrInvalidOrder, "Order contains %d vertices, graph has %d vertices", len(order), len(graph.Vertices))
+	}
+	internalOrder := make([]int64, len(order))
+	seen := make([]bool, len(graph.Vertices))
+	for i, label := range order {
+		vertexNum, ok := graph.mapping[label]
+		if !ok {
+			return nil, errors.Wrapf(ErrVertexNotFound, "Vertex %d", label)
+		}
+		if seen[vertexNum] {
+			return nil, errors.Wrapf(ErrInvalidOrder, "Vertex %d is presented more than once", label)
+		}
+		seen[vertexNum] = true
+		internalOrder[i] = vertexNum
+	}
+	return internalOrder, nil
+}
@@ -0,0 +1,74 @@
+package ch
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrepareWithOrder(t *testing.T) {
+	const size = 20
+	g := generateGridGraph(size)
+	order := make([]int64, 0, size*size)
+	for i := range g.Vertices {
+		order = append(order, g.Vertices[i].Label)
+	}
+	rnd := rand.New(rand.NewSource(1337))
+	rnd.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+
+	err := g.PrepareWithOrder(order)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i, label := range order {
+		vertexNum, _ := g.FindVertex(label)
+		assert.Equal(t, int64(i), g.Vertices[vertexNum].OrderPos())
+		assert.Equal(t, vertexNum, g.contractionOrder[i])
+	}
+
+	check := func() {
+		for _, source := range []int64{0, 17, 210, 399} {
+			for target := int64(0); target < size*size; target += 7 {
+				expectedCost, _ := g.VanillaShortestPath(source, target)
+				cost, _ := g.ShortestPath(source, target)
+				if math.Abs(expectedCost-cost) > eps {
+					t.Errorf("Cost of path %d -> %d should be %f, but got %f", source, target, expectedCost, cost)
+					return
+				}
+			}
+		}
+	}
+	check()
+
+	// Recustomization should work as well
+	from, _ := g.FindVertex(210)
+	to, _ := g.FindVertex(211)
+	initialCost := g.getEdgeCost(from, to)
+	err = g.UpdateEdgeWeight(210, 211, 100, true)
+	assert.NoError(t, err)
+	err = g.UpdateEdgeWeight(210, 211, initialCost, true)
+	assert.NoError(t, err)
+	check()
+}
+
+func TestPrepareWithOrderInvalid(t *testing.T) {
+	g := generateGridGraph(3)
+	err := g.PrepareWithOrder([]int64{0, 1, 2})
+	assert.Equal(t, ErrInvalidOrder, errors.Cause(err))
+	err = g.PrepareWithOrder([]int64{0, 1, 2, 3, 4, 5, 6, 7, 7})
+	assert.Equal(t, ErrInvalidOrder, errors.Cause(err))
+	err = g.PrepareWithOrder([]int64{0, 1, 2, 3, 4, 5, 6, 7, 100})
+	assert.Equal(t, ErrVertexNotFound, errors.Cause(err))
+	assert.False(t, g.chPrepared)
+
+	err = g.PrepareWithOrder([]int64{0, 1, 2, 3, 4, 5, 6, 7, 8})
+	assert.NoError(t, err)
+	shortcutsNum := g.GetShortcutsNum()
+	err = g.PrepareWithOrder([]int64{8, 7, 6, 5, 4, 3, 2, 1, 0})
+	assert.Equal(t, ErrCHAlreadyPrepared, err)
+	assert.Equal(t, shortcutsNum, g.GetShortcutsNum())
+}
@@ -0,0 +1,166 @@
+package ch
+
+import (
+	"sort"
+)
+
+// queryGraph Read-only flat (CSR - compressed sparse row) representation of prepared contraction hierarchies.
+// It contains only "upward" arcs, so bidirectional search does not need to compare order positions of vertices.
+//
+// labels - User's defined IDs of vertices (indexed by library defined ID)
+// mapping - User's defined ID to library defined ID (could be nil: then sortedLabels and sortedIDs are used for binary search)
+// offsets - Arcs of vertex V are stored in range [offsets[d][V]; offsets[d][V+1])
+// targets - For forward direction: head of arc V -> T. For backward direction: tail of arc T -> V. In both cases T is higher in hierarchies than V.
+// weights - Travel costs of arcs
+// via - Library defined ID of vertex through which the shortcut exists (-1 for initial edges)
+type queryGraph struct {
+	labels       []int64
+	mapping      map[int64]int64
+	sortedLabels []int64
+	sortedIDs    []int64
+
+	offsets [directionsCount][]int64
+	targets [directionsCount][]int64
+	weights [directionsCount][]float64
+	via     [directionsCount][]int64
+}
+
+// buildQueryGraph Builds flat representation of current state of contraction hierarchies.
+// Parallel arcs are merged: only the cheapest one is kept (with its Via-vertex, so initial edge parallel to shortcut is not expanded).
+func (graph *Graph) buildQueryGraph() *queryGraph {
+	n := len(graph.Vertices)
+	q := &queryGraph{
+		labels:  make([]int64, n),
+		mapping: graph.mapping,
+	}
+	for i := range graph.Vertices {
+		q.labels[i] = graph.Vertices[i].Label
+	}
+	// Reusable buffer for merging parallel arcs: target -> index of arc
+	arcIdx := make(map[int64]int64)
+	for d := forward; d < directionsCount; d++ {
+		q.offsets[d] = make([]int64, n+1)
+		for i := range graph.Vertices {
+			vertex := &graph.Vertices[i]
+			var vertexList []incidentEdge
+			if d == forward {
+				vertexList = vertex.outIncidentEdges
+			} else {
+				vertexList = vertex.inIncidentEdges
+			}
+			for k := range arcIdx {
+				delete(arcIdx, k)
+			}
+			for _, edge := range vertexList {
+				if vertex.orderPos >= graph.Vertices[edge.vertexID].orderPos {
+					continue
+				}
+				from, to := int64(i), edge.vertexID
+				if d == backward {
+					from, to = to, from
+				}
+				if idx, ok := arcIdx[edge.vertexID]; ok {
+					if edge.weight < q.weights[d][idx] {
+						q.weights[d][idx] = edge.weight
+						q.via[d][idx] = graph.incidentEdgeVia(from, to, edge)
+					}
+					continue
+				}
+				arcIdx[edge.vertexID] = int64(len(q.targets[d]))
+				q.targets[d] = append(q.targets[d], edge.vertexID)
+				q.weights[d] = append(q.weights[d], edge.weight)
+				q.via[d] = append(q.via[d], graph.incidentEdgeVia(from, to, edge))
+			}
+			q.offsets[d][i+1] = int64(len(q.targets[d]))
+		}
+	}
+	return q
+}
+
+// incidentEdgeVia Returns Via-vertex of arc from -> to (library defined IDs) represented by given incident edge: -1 for initial edge
+func (graph *Graph) incidentEdgeVia(from, to int64, edge incidentEdge) int64 {
+	if !edge.shortcut {
+		return -1
+	}
+	return graph.arcViaVertex(from, to)
+}
+
+// verticesNum Returns number of vertices
+func (q *queryGraph) verticesNum() int {
+	return len(q.labels)
+}
+
+// vertexIndex Returns library defined ID of vertex by user's defined ID
+func (q *queryGraph) vertexIndex(label int64) (int64, bool) {
+	if q.mapping != nil {
+		idx, ok := q.mapping[label]
+		return idx, ok
+	}
+	i := sort.Search(len(q.sortedLabels), func(i int) bool { return q.sortedLabels[i] >= label })
+	if i < len(q.sortedLabels) && q.sortedLabels[i] == label {
+		return q.sortedIDs[i], true
+	}
+	return -1, false
+}
+
+// arcVia Returns Via-vertex of arc from -> to (-1 if arc is an initial edge or if there is no such arc)
+func (q *queryGraph) arcVia(from, to int64) int64 {
+	// Arc is stored either as forward arc of its tail or as backward arc of its head (depends on which one is lower in hierarchies)
+	for e := q.offsets[forward][from]; e < q.offsets[forward][from+1]; e++ {
+		if q.targets[forward][e] == to {
+			return q.via[forward][e]
+		}
+	}
+	for e := q.offsets[backward][to]; e < q.offsets[backward][to+1]; e++ {
+		if q.targets[backward][e] == from {
+			return q.via[backward][e]
+		}
+	}
+	return -1
+}
+
+// computePath Returns slice of IDs (user defined) of computed path. See Graph.ComputePath(...)
+func (q *queryGraph) computePath(middleID int64, forwardPrev, backwardPrev map[int64]int64) []int64 {
+	path := joinPathParts(middleID, forwardPrev, backwardPrev)
+
+	// Expand shortcuts iteratively
+	for {
+		expanded := false
+		newPath := make([]int64, 0, len(path)*2)
+		for i := 0; i < len(path); i++ {
+			newPath = append(newPath, path[i])
+			if i+1 < len(path) {
+				if via := q.arcVia(path[i], path[i+1]); via >= 0 {
+					newPath = append(newPath, via)
+					expanded = true
+				}
+			}
+		}
+		path = newPath
+		if !expanded {
+			break
+		}
+	}
+
+	// Convert internal IDs to user labels
+	for i := range path {
+		path[i] = q.labels[path[i]]
+	}
+	return path
+}
+
+// vertexAlternativesToInternal See Graph.vertexAlternativesToInternal(...)
+func (q *queryGraph) vertexAlternativesToInternal(alternatives []VertexAlternative) []vertexAlternativeInternal {
+	result := make([]vertexAlternativeInternal, 0, len(alternatives))
+	for _, alternative := range alternatives {
+		vertexNum, ok := q.vertexIndex(alternative.Label)
+		if !ok {
+			vertexNum = vertexNotFound
+		}
+		result = append(result, vertexAlternativeInternal{
+			vertexNum:          vertexNum,
+			additionalDistance: alternative.AdditionalDistance,
+		})
+	}
+	return result
+}
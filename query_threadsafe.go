@@ -29,16 +29,34 @@ type QueryState struct {
 
 // QueryPool provides thread-safe access to pooled QueryState objects.
 // Use this when you need to call shortest path queries from multiple goroutines.
+//
+// Queries are executed on flat read-only representation of contraction hierarchies (see MappedGraph also).
 type QueryPool struct {
-	pool  sync.Pool
+	pool sync.Pool
+	// Graph which flat representation is used for queries (nil if pool has been created for MappedGraph)
 	graph *Graph
+	// Flat representation to be used if graph is nil
+	query *queryGraph
+	// Named metric which flat representation is used for queries (see NewMetricQueryPool(...))
+	metric *graphMetric
 }
 
 // NewQueryPool creates a new QueryPool for concurrent query execution.
 // The pool lazily initializes QueryState objects as needed.
+//
+// Pool runs queries on the current snapshot of graph (see Snapshot()) and switches to new snapshots atomically once they are published by recustomization,
+// so Recustomize() and similar functions could be called while queries are running.
+// Weights updated by UpdateEdgeWeight(..., false) are not visible to pool until recustomization.
 func (graph *Graph) NewQueryPool() *QueryPool {
+	// Snapshot is built here rather than by the first query, so it never races with recustomization
+	graph.Snapshot()
+	return newQueryPool(graph, nil)
+}
+
+func newQueryPool(graph *Graph, query *queryGraph) *QueryPool {
 	return &QueryPool{
 		graph: graph,
+		query: query,
 		pool: sync.Pool{
 			New: func() interface{} {
 				return &QueryState{}
@@ -47,10 +65,21 @@ func (graph *Graph) NewQueryPool() *QueryPool {
 	}
 }
 
+// queryGraph Returns flat representation of contraction hierarchies to run single query on
+func (qp *QueryPool) queryGraph() *queryGraph {
+	if qp.metric != nil {
+		return qp.metric.loadSnapshot().query
+	}
+	if qp.graph != nil {
+		return qp.graph.sharedQueryGraph()
+	}
+	return qp.query
+}
+
 // acquireState gets a QueryState from the pool and initializes it if needed
-func (qp *QueryPool) acquireState() *QueryState {
+func (qp *QueryPool) acquireState(q *queryGraph) *QueryState {
 	state := qp.pool.Get().(*QueryState)
-	n := len(qp.graph.Vertices)
+	n := q.verticesNum()
 
 	// Lazy initialization of buffers (only on first use of this state)
 	if state.dist[forward] == nil || len(state.dist[forward]) != n {
@@ -90,21 +119,22 @@ func (qp *QueryPool) ShortestPath(source, target int64) (float64, []int64) {
 		return 0, []int64{source}
 	}
 
+	q := qp.queryGraph()
 	endpoints := [directionsCount]int64{source, target}
 	for d, endpoint := range endpoints {
 		var ok bool
-		if endpoints[d], ok = qp.graph.mapping[endpoint]; !ok {
+		if endpoints[d], ok = q.vertexIndex(endpoint); !ok {
 			return -1.0, nil
 		}
 	}
 
-	state := qp.acquireState()
+	state := qp.acquireState(q)
 	defer qp.releaseState(state)
 
-	return qp.shortestPath(state, endpoints)
+	return qp.shortestPath(q, state, endpoints)
 }
 
-func (qp *QueryPool) shortestPath(state *QueryState, endpoints [directionsCount]int64) (float64, []int64) {
+func (qp *QueryPool) shortestPath(q *queryGraph, state *QueryState, endpoints [directionsCount]int64) (float64, []int64) {
 	for d := forward; d < directionsCount; d++ {
 		state.epochs[d][endpoints[d]] = state.epoch
 		state.dist[d][endpoints[d]] = 0
@@ -114,10 +144,10 @@ func (qp *QueryPool) shortestPath(state *QueryState, endpoints [directionsCount]
 		}
 		heap.Push(state.queues[d], heapEndpoint)
 	}
-	return qp.shortestPathCore(state)
+	return qp.shortestPathCore(q, state)
 }
 
-func (qp *QueryPool) shortestPathCore(state *QueryState) (float64, []int64) {
+func (qp *QueryPool) shortestPathCore(q *queryGraph, state *QueryState) (float64, []int64) {
 	estimate := Infinity
 	middleID := int64(-1)
 
@@ -129,7 +159,7 @@ func (qp *QueryPool) shortestPathCore(state *QueryState) (float64, []int64) {
 			}
 			queuesProcessed = true
 			reverseDirection := (d + 1) % directionsCount
-			qp.directionalSearch(state, d, reverseDirection, &estimate, &middleID)
+			qp.directionalSearch(q, state, d, reverseDirection, &estimate, &middleID)
 		}
 		if !queuesProcessed {
 			break
@@ -139,35 +169,27 @@ func (qp *QueryPool) shortestPathCore(state *QueryState) (float64, []int64) {
 	if estimate == Infinity {
 		return -1.0, nil
 	}
-	return estimate, qp.graph.ComputePath(middleID, state.prev[forward], state.prev[backward])
+	return estimate, q.computePath(middleID, state.prev[forward], state.prev[backward])
 }
 
-func (qp *QueryPool) directionalSearch(state *QueryState, d direction, reverseDirection direction, estimate *float64, middleID *int64) {
+func (qp *QueryPool) directionalSearch(q *queryGraph, state *QueryState, d direction, reverseDirection direction, estimate *float64, middleID *int64) {
 	vertex := heap.Pop(state.queues[d]).(*vertexDist)
 	if vertex.dist <= *estimate {
 		state.epochs[d][vertex.id] = state.epoch
-		// Edge relaxation
-		var vertexList []incidentEdge
-		if d == forward {
-			vertexList = qp.graph.Vertices[vertex.id].outIncidentEdges
-		} else {
-			vertexList = qp.graph.Vertices[vertex.id].inIncidentEdges
-		}
-		for i := range vertexList {
-			temp := vertexList[i].vertexID
-			cost := vertexList[i].weight
-			if qp.graph.Vertices[vertex.id].orderPos < qp.graph.Vertices[temp].orderPos {
-				alt := state.dist[d][vertex.id] + cost
-				if state.epochs[d][temp] != state.epoch || state.dist[d][temp] > alt {
-					state.dist[d][temp] = alt
-					state.epochs[d][temp] = state.epoch
-					state.prev[d][temp] = vertex.id
-					node := &vertexDist{
-						id:   temp,
-						dist: alt,
-					}
-					heap.Push(state.queues[d], node)
+		// Edge relaxation (only upward arcs are stored)
+		for e := q.offsets[d][vertex.id]; e < q.offsets[d][vertex.id+1]; e++ {
+			temp := q.targets[d][e]
+			cost := q.weights[d][e]
+			alt := state.dist[d][vertex.id] + cost
+			if state.epochs[d][temp] != state.epoch || state.dist[d][temp] > alt {
+				state.dist[d][temp] = alt
+				state.epochs[d][temp] = state.epoch
+				state.prev[d][temp] = vertex.id
+				node := &vertexDist{
+					id:   temp,
+					dist: alt,
 				}
+				heap.Push(state.queues[d], node)
 			}
 		}
 	}
@@ -186,19 +208,20 @@ func (qp *QueryPool) directionalSearch(state *QueryState, d direction, reverseDi
 // sources - user's defined source vertices with additional penalties
 // targets - user's defined target vertices with additional penalties
 func (qp *QueryPool) ShortestPathWithAlternatives(sources, targets []VertexAlternative) (float64, []int64) {
+	q := qp.queryGraph()
 	endpoints := [directionsCount][]VertexAlternative{sources, targets}
 	var endpointsInternal [directionsCount][]vertexAlternativeInternal
 	for d, alternatives := range endpoints {
-		endpointsInternal[d] = qp.graph.vertexAlternativesToInternal(alternatives)
+		endpointsInternal[d] = q.vertexAlternativesToInternal(alternatives)
 	}
 
-	state := qp.acquireState()
+	state := qp.acquireState(q)
 	defer qp.releaseState(state)
 
-	return qp.shortestPathWithAlternatives(state, endpointsInternal)
+	return qp.shortestPathWithAlternatives(q, state, endpointsInternal)
 }
 
-func (qp *QueryPool) shortestPathWithAlternatives(state *QueryState, endpoints [directionsCount][]vertexAlternativeInternal) (float64, []int64) {
+func (qp *QueryPool) shortestPathWithAlternatives(q *queryGraph, state *QueryState, endpoints [directionsCount][]vertexAlternativeInternal) (float64, []int64) {
 	for d := forward; d < directionsCount; d++ {
 		for _, endpoint := range endpoints[d] {
 			if endpoint.vertexNum == vertexNotFound {
@@ -213,7 +236,7 @@ func (qp *QueryPool) shortestPathWithAlternatives(state *QueryState, endpoints [
 			heap.Push(state.queues[d], heapEndpoint)
 		}
 	}
-	return qp.shortestPathCore(state)
+	return qp.shortestPathCore(q, state)
 }
 
 // ShortestPathOneToMany computes shortest paths from single source to multiple targets (thread-safe).
@@ -222,17 +245,18 @@ func (qp *QueryPool) shortestPathWithAlternatives(state *QueryState, endpoints [
 // source - user's defined ID of source vertex
 // targets - set of user's defined IDs of target vertices
 func (qp *QueryPool) ShortestPathOneToMany(source int64, targets []int64) ([]float64, [][]int64) {
+	q := qp.queryGraph()
 	estimateAll := make([]float64, 0, len(targets))
 	pathAll := make([][]int64, 0, len(targets))
 
 	var ok bool
-	if source, ok = qp.graph.mapping[source]; !ok {
+	if source, ok = q.vertexIndex(source); !ok {
 		estimateAll = append(estimateAll, -1.0)
 		pathAll = append(pathAll, nil)
 		return estimateAll, pathAll
 	}
 
-	state := qp.acquireState()
+	state := qp.acquireState(q)
 	defer qp.releaseState(state)
 
 	for _, target := range targets {
@@ -246,7 +270,7 @@ func (qp *QueryPool) ShortestPathOneToMany(source int64, targets []int64) ([]flo
 			continue
 		}
 		var ok bool
-		if target, ok = qp.graph.mapping[target]; !ok {
+		if target, ok = q.vertexIndex(target); !ok {
 			estimateAll = append(estimateAll, -1.0)
 			pathAll = append(pathAll, nil)
 			continue
@@ -271,7 +295,7 @@ func (qp *QueryPool) ShortestPathOneToMany(source int64, targets []int64) ([]flo
 		heap.Push(forwQ, heapSource)
 		heap.Push(backwQ, heapTarget)
 
-		estimate, path := qp.shortestPathOneToManyCore(state, epoch, forwQ, backwQ)
+		estimate, path := qp.shortestPathOneToManyCore(q, state, epoch, forwQ, backwQ)
 		estimateAll = append(estimateAll, estimate)
 		pathAll = append(pathAll, path)
 	}
@@ -279,7 +303,7 @@ func (qp *QueryPool) ShortestPathOneToMany(source int64, targets []int64) ([]flo
 	return estimateAll, pathAll
 }
 
-func (qp *QueryPool) shortestPathOneToManyCore(state *QueryState, epoch int64, forwQ *vertexDistHeap, backwQ *vertexDistHeap) (float64, []int64) {
+func (qp *QueryPool) shortestPathOneToManyCore(q *queryGraph, state *QueryState, epoch int64, forwQ *vertexDistHeap, backwQ *vertexDistHeap) (float64, []int64) {
 	estimate := Infinity
 	var middleID int64
 
@@ -288,7 +312,7 @@ func (qp *QueryPool) shortestPathOneToManyCore(state *QueryState, epoch int64, f
 			vertex1 := heap.Pop(forwQ).(*vertexDist)
 			if vertex1.dist <= estimate {
 				state.epochs[forward][vertex1.id] = epoch
-				qp.relaxEdgesBiForward(state, vertex1, forwQ, epoch)
+				qp.relaxEdgesBiForward(q, state, vertex1, forwQ, epoch)
 			}
 			if state.epochs[backward][vertex1.id] == epoch {
 				if vertex1.dist+state.dist[backward][vertex1.id] < estimate {
@@ -302,7 +326,7 @@ func (qp *QueryPool) shortestPathOneToManyCore(state *QueryState, epoch int64, f
 			vertex2 := heap.Pop(backwQ).(*vertexDist)
 			if vertex2.dist <= estimate {
 				state.epochs[backward][vertex2.id] = epoch
-				qp.relaxEdgesBiBackward(state, vertex2, backwQ, epoch)
+				qp.relaxEdgesBiBackward(q, state, vertex2, backwQ, epoch)
 			}
 			if state.epochs[forward][vertex2.id] == epoch {
 				if vertex2.dist+state.dist[forward][vertex2.id] < estimate {
@@ -316,41 +340,35 @@ func (qp *QueryPool) shortestPathOneToManyCore(state *QueryState, epoch int64, f
 	if estimate == Infinity {
 		return -1, nil
 	}
-	return estimate, qp.graph.ComputePath(middleID, state.prev[forward], state.prev[backward])
+	return estimate, q.computePath(middleID, state.prev[forward], state.prev[backward])
 }
 
-func (qp *QueryPool) relaxEdgesBiForward(state *QueryState, vertex *vertexDist, forwQ *vertexDistHeap, epoch int64) {
-	vertexList := qp.graph.Vertices[vertex.id].outIncidentEdges
-	for i := range vertexList {
-		temp := vertexList[i].vertexID
-		cost := vertexList[i].weight
-		if qp.graph.Vertices[vertex.id].orderPos < qp.graph.Vertices[temp].orderPos {
-			alt := state.dist[forward][vertex.id] + cost
-			if state.epochs[forward][temp] != epoch || state.dist[forward][temp] > alt {
-				state.dist[forward][temp] = alt
-				state.prev[forward][temp] = vertex.id
-				state.epochs[forward][temp] = epoch
-				node := &vertexDist{id: temp, dist: alt}
-				heap.Push(forwQ, node)
-			}
+func (qp *QueryPool) relaxEdgesBiForward(q *queryGraph, state *QueryState, vertex *vertexDist, forwQ *vertexDistHeap, epoch int64) {
+	for e := q.offsets[forward][vertex.id]; e < q.offsets[forward][vertex.id+1]; e++ {
+		temp := q.targets[forward][e]
+		cost := q.weights[forward][e]
+		alt := state.dist[forward][vertex.id] + cost
+		if state.epochs[forward][temp] != epoch || state.dist[forward][temp] > alt {
+			state.dist[forward][temp] = alt
+			state.prev[forward][temp] = vertex.id
+			state.epochs[forward][temp] = epoch
+			node := &vertexDist{id: temp, dist: alt}
+			heap.Push(forwQ, node)
 		}
 	}
 }
 
-func (qp *QueryPool) relaxEdgesBiBackward(state *QueryState, vertex *vertexDist, backwQ *vertexDistHeap, epoch int64) {
-	vertexList := qp.graph.Vertices[vertex.id].inIncidentEdges
-	for i := range vertexList {
-		temp := vertexList[i].vertexID
-		cost := vertexList[i].weight
-		if qp.graph.Vertices[vertex.id].orderPos < qp.graph.Vertices[temp].orderPos {
-			alt := state.dist[backward][vertex.id] + cost
-			if state.epochs[backward][temp] != epoch || state.dist[backward][temp] > alt {
-				state.dist[backward][temp] = alt
-				state.prev[backward][temp] = vertex.id
-				state.epochs[backward][temp] = epoch
-				node := &vertexDist{id: temp, dist: alt}
-				heap.Push(backwQ, node)
-			}
+func (qp *QueryPool) relaxEdgesBiBackward(q *queryGraph, state *QueryState, vertex *vertexDist, backwQ *vertexDistHeap, epoch int64) {
+	for e := q.offsets[backward][vertex.id]; e < q.offsets[backward][vertex.id+1]; e++ {
+		temp := q.targets[backward][e]
+		cost := q.weights[backward][e]
+		alt := state.dist[backward][vertex.id] + cost
+		if state.epochs[backward][temp] != epoch || state.dist[backward][temp] > alt {
+			state.dist[backward][temp] = alt
+			state.prev[backward][temp] = vertex.id
+			state.epochs[backward][temp] = epoch
+			node := &vertexDist{id: temp, dist: alt}
+			heap.Push(backwQ, node)
 		}
 	}
 }
@@ -358,19 +376,20 @@ func (qp *QueryPool) relaxEdgesBiBackward(state *QueryState, vertex *vertexDist,
 // ShortestPathOneToManyWithAlternatives computes shortest paths with alternatives (thread-safe).
 // This method can be safely called from multiple goroutines concurrently.
 func (qp *QueryPool) ShortestPathOneToManyWithAlternatives(sourceAlternatives []VertexAlternative, targetsAlternatives [][]VertexAlternative) ([]float64, [][]int64) {
+	q := qp.queryGraph()
 	estimateAll := make([]float64, 0, len(targetsAlternatives))
 	pathAll := make([][]int64, 0, len(targetsAlternatives))
 
-	sourceAlternativesInternal := qp.graph.vertexAlternativesToInternal(sourceAlternatives)
+	sourceAlternativesInternal := q.vertexAlternativesToInternal(sourceAlternatives)
 
-	state := qp.acquireState()
+	state := qp.acquireState(q)
 	defer qp.releaseState(state)
 
 	for _, targetAlternatives := range targetsAlternatives {
 		state.epoch++
 		epoch := state.epoch
 
-		targetAlternativesInternal := qp.graph.vertexAlternativesToInternal(targetAlternatives)
+		targetAlternativesInternal := q.vertexAlternativesToInternal(targetAlternatives)
 
 		// Reset prev maps for this query
 		state.prev[forward] = make(map[int64]int64)
@@ -407,7 +426,7 @@ func (qp *QueryPool) ShortestPathOneToManyWithAlternatives(sourceAlternatives []
 			heap.Push(backwQ, heapTarget)
 		}
 
-		estimate, path := qp.shortestPathOneToManyCore(state, epoch, forwQ, backwQ)
+		estimate, path := qp.shortestPathOneToManyCore(q, state, epoch, forwQ, backwQ)
 		estimateAll = append(estimateAll, estimate)
 		pathAll = append(pathAll, path)
 	}
@@ -427,25 +446,26 @@ func (qp *QueryPool) ShortestPathManyToMany(sources, targets []int64) ([][]float
 	copy(sourcesCopy, sources)
 	copy(targetsCopy, targets)
 
+	q := qp.queryGraph()
 	endpoints := [directionsCount][]int64{sourcesCopy, targetsCopy}
 	for d, directionEndpoints := range endpoints {
 		for i, endpoint := range directionEndpoints {
 			var ok bool
-			if endpoints[d][i], ok = qp.graph.mapping[endpoint]; !ok {
+			if endpoints[d][i], ok = q.vertexIndex(endpoint); !ok {
 				endpoints[d][i] = -1
 			}
 		}
 	}
 
-	state := qp.acquireState()
+	state := qp.acquireState(q)
 	defer qp.releaseState(state)
 
-	return qp.shortestPathManyToMany(state, endpoints)
+	return qp.shortestPathManyToMany(q, state, endpoints)
 }
 
 // initManyToManyBuffers ensures buffers are allocated and properly sized for the query
-func (qp *QueryPool) initManyToManyBuffers(state *QueryState, numSources, numTargets int) {
-	n := len(qp.graph.Vertices)
+func (qp *QueryPool) initManyToManyBuffers(q *queryGraph, state *QueryState, numSources, numTargets int) {
+	n := q.verticesNum()
 	endpointCounts := [directionsCount]int{numSources, numTargets}
 
 	for d := forward; d < directionsCount; d++ {
@@ -489,13 +509,13 @@ func (qp *QueryPool) setManyToManyDist(state *QueryState, d direction, endpointI
 	state.manyToManyEpochs[d][endpointIdx][vertexID] = state.manyToManyEpoch
 }
 
-func (qp *QueryPool) shortestPathManyToMany(state *QueryState, endpoints [directionsCount][]int64) ([][]float64, [][][]int64) {
+func (qp *QueryPool) shortestPathManyToMany(q *queryGraph, state *QueryState, endpoints [directionsCount][]int64) ([][]float64, [][][]int64) {
 	numSources := len(endpoints[forward])
 	numTargets := len(endpoints[backward])
 
 	// Increment epoch for lazy buffer clearing
 	state.manyToManyEpoch++
-	qp.initManyToManyBuffers(state, numSources, numTargets)
+	qp.initManyToManyBuffers(q, state, numSources, numTargets)
 
 	// Clear prev maps
 	for d := forward; d < directionsCount; d++ {
@@ -560,7 +580,7 @@ func (qp *QueryPool) shortestPathManyToMany(state *QueryState, endpoints [direct
 					continue
 				}
 				queuesProcessed = true
-				qp.directionalSearchManyToMany(state, d, endpointIdx, queues, estimates, middleIDs, numSources, numTargets)
+				qp.directionalSearchManyToMany(q, state, d, endpointIdx, queues, estimates, middleIDs, numSources, numTargets)
 			}
 		}
 		if !queuesProcessed {
@@ -577,7 +597,7 @@ func (qp *QueryPool) shortestPathManyToMany(state *QueryState, endpoints [direct
 				estimates[sourceIdx][targetIdx] = -1
 				continue
 			}
-			paths[sourceIdx][targetIdx] = qp.graph.ComputePath(
+			paths[sourceIdx][targetIdx] = q.computePath(
 				middleIDs[sourceIdx][targetIdx],
 				state.manyToManyPrev[forward][sourceIdx],
 				state.manyToManyPrev[backward][targetIdx],
@@ -588,7 +608,7 @@ func (qp *QueryPool) shortestPathManyToMany(state *QueryState, endpoints [direct
 	return estimates, paths
 }
 
-func (qp *QueryPool) directionalSearchManyToMany(state *QueryState, d direction, endpointIdx int, queues [directionsCount][]*vertexDistHeap, estimates [][]float64, middleIDs [][]int64, numSources, numTargets int) {
+func (qp *QueryPool) directionalSearchManyToMany(qg *queryGraph, state *QueryState, d direction, endpointIdx int, queues [directionsCount][]*vertexDistHeap, estimates [][]float64, middleIDs [][]int64, numSources, numTargets int) {
 	q := queues[d][endpointIdx]
 	vertex := heap.Pop(q).(*vertexDist)
 
@@ -598,27 +618,16 @@ func (qp *QueryPool) directionalSearchManyToMany(state *QueryState, d direction,
 		return
 	}
 
-	// Edge relaxation
-	var vertexList []incidentEdge
-	if d == forward {
-		vertexList = qp.graph.Vertices[vertex.id].outIncidentEdges
-	} else {
-		vertexList = qp.graph.Vertices[vertex.id].inIncidentEdges
-	}
-
-	for i := range vertexList {
-		temp := vertexList[i].vertexID
-		cost := vertexList[i].weight
-
-		// Only explore upward in CH
-		if qp.graph.Vertices[vertex.id].orderPos < qp.graph.Vertices[temp].orderPos {
-			alt := vertex.dist + cost
-			tempDist := qp.getManyToManyDist(state, d, endpointIdx, temp)
-			if alt < tempDist {
-				qp.setManyToManyDist(state, d, endpointIdx, temp, alt)
-				state.manyToManyPrev[d][endpointIdx][temp] = vertex.id
-				heap.Push(q, &vertexDist{id: temp, dist: alt})
-			}
+	// Edge relaxation (only upward arcs are stored)
+	for e := qg.offsets[d][vertex.id]; e < qg.offsets[d][vertex.id+1]; e++ {
+		temp := qg.targets[d][e]
+		cost := qg.weights[d][e]
+		alt := vertex.dist + cost
+		tempDist := qp.getManyToManyDist(state, d, endpointIdx, temp)
+		if alt < tempDist {
+			qp.setManyToManyDist(state, d, endpointIdx, temp, alt)
+			state.manyToManyPrev[d][endpointIdx][temp] = vertex.id
+			heap.Push(q, &vertexDist{id: temp, dist: alt})
 		}
 	}
 
@@ -655,28 +664,29 @@ func (qp *QueryPool) directionalSearchManyToMany(state *QueryState, d direction,
 // sourcesAlternatives - set of user's defined IDs of source vertices with additional penalty
 // targetsAlternatives - set of user's defined IDs of target vertices with additional penalty
 func (qp *QueryPool) ShortestPathManyToManyWithAlternatives(sourcesAlternatives, targetsAlternatives [][]VertexAlternative) ([][]float64, [][][]int64) {
+	q := qp.queryGraph()
 	endpoints := [directionsCount][][]VertexAlternative{sourcesAlternatives, targetsAlternatives}
 	var endpointsInternal [directionsCount][][]vertexAlternativeInternal
 	for d, directionEndpoints := range endpoints {
 		endpointsInternal[d] = make([][]vertexAlternativeInternal, 0, len(directionEndpoints))
 		for _, alternatives := range directionEndpoints {
-			endpointsInternal[d] = append(endpointsInternal[d], qp.graph.vertexAlternativesToInternal(alternatives))
+			endpointsInternal[d] = append(endpointsInternal[d], q.vertexAlternativesToInternal(alternatives))
 		}
 	}
 
-	state := qp.acquireState()
+	state := qp.acquireState(q)
 	defer qp.releaseState(state)
 
-	return qp.shortestPathManyToManyWithAlternatives(state, endpointsInternal)
+	return qp.shortestPathManyToManyWithAlternatives(q, state, endpointsInternal)
 }
 
-func (qp *QueryPool) shortestPathManyToManyWithAlternatives(state *QueryState, endpoints [directionsCount][][]vertexAlternativeInternal) ([][]float64, [][][]int64) {
+func (qp *QueryPool) shortestPathManyToManyWithAlternatives(q *queryGraph, state *QueryState, endpoints [directionsCount][][]vertexAlternativeInternal) ([][]float64, [][][]int64) {
 	numSources := len(endpoints[forward])
 	numTargets := len(endpoints[backward])
 
 	// Increment epoch for lazy buffer clearing
 	state.manyToManyEpoch++
-	qp.initManyToManyBuffers(state, numSources, numTargets)
+	qp.initManyToManyBuffers(q, state, numSources, numTargets)
 
 	// Clear prev maps
 	for d := forward; d < directionsCount; d++ {
@@ -746,7 +756,7 @@ func (qp *QueryPool) shortestPathManyToManyWithAlternatives(state *QueryState, e
 					continue
 				}
 				queuesProcessed = true
-				qp.directionalSearchManyToMany(state, d, endpointIdx, queues, estimates, middleIDs, numSources, numTargets)
+				qp.directionalSearchManyToMany(q, state, d, endpointIdx, queues, estimates, middleIDs, numSources, numTargets)
 			}
 		}
 		if !queuesProcessed {
@@ -763,7 +773,7 @@ func (qp *QueryPool) shortestPathManyToManyWithAlternatives(state *QueryState, e
 				estimates[sourceIdx][targetIdx] = -1
 				continue
 			}
-			paths[sourceIdx][targetIdx] = qp.graph.ComputePath(
+			paths[sourceIdx][targetIdx] = q.computePath(
 				middleIDs[sourceIdx][targetIdx],
 				state.manyToManyPrev[forward][sourceIdx],
 				state.manyToManyPrev[backward][targetIdx],
@@ -3,6 +3,7 @@ package ch
 import (
 	"math"
 	"math/rand"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -41,6 +42,36 @@ func TestQueryPoolShortestPath(t *testing.T) {
 	t.Log("TestQueryPoolShortestPath is Ok!")
 }
 
+func TestQueryPoolParallelShortcut(t *testing.T) {
+	// Initial edge 1 -> 2 becomes cheaper than parallel shortcut 1 -> 2 (via 3)
+	g := parallelShortcutGraph(t)
+	err := g.UpdateEdgeWeight(1, 2, 0.5, true)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	pool := newQueryPool(g, g.buildQueryGraph())
+	expected := []struct {
+		target int64
+		cost   float64
+		path   []int64
+	}{
+		{2, 0.5, []int64{1, 2}},
+		{4, 10.5, []int64{1, 2, 4}},
+	}
+	for _, e := range expected {
+		ansPool, pathPool := pool.ShortestPath(1, e.target)
+		if math.Abs(ansPool-e.cost) > eps {
+			t.Errorf("Cost of path should be %f, but got %f", e.cost, ansPool)
+			return
+		}
+		if !reflect.DeepEqual(pathPool, e.path) {
+			t.Errorf("Path should be %v, but got %v", e.path, pathPool)
+			return
+		}
+	}
+}
+
 func TestQueryPoolConcurrentQueries(t *testing.T) {
 	g := Graph{}
 	err := graphFromCSV(&g, "./data/pgrouting_osm.csv")
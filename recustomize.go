@@ -8,6 +8,8 @@ package ch
 // weight - New weight for the edge
 // needRecustom - If true, Recustomize() is called automatically after update
 //
+// QueryPool uses updated weight only after recustomization (see Snapshot()).
+//
 // Returns error if edge is not found or if recustomization fails.
 func (graph *Graph) UpdateEdgeWeight(from, to int64, weight float64, needRecustom bool) error {
 	fromInternal, ok := graph.mapping[from]
@@ -41,11 +43,20 @@ func (graph *Graph) UpdateEdgeWeight(from, to int64, weight float64, needRecusto
 // This is useful after edge weights have been modified (e.g., traffic updates).
 // The contraction order is preserved - only the metric (costs) is updated.
 //
+// If graph has been prepared by PrepareCustomizable() (or RecustomizeTriangles() has been called) then lower triangles are enumerated (see customize()).
+// For graph prepared by PrepareCustomizable() shortest paths are exact for any metric.
+//
+// New snapshot is published for QueryPool atomically once all costs are recomputed (see Snapshot()).
+//
 // Returns error if CH has not been prepared yet.
 func (graph *Graph) Recustomize() error {
 	if !graph.chPrepared {
 		return ErrCHNotPrepared
 	}
+	if graph.cch != nil {
+		graph.customize(false)
+		return nil
+	}
 
 	// Process vertices in contraction order
 	// Shortcuts via vertex V are processed after all shortcuts via vertices
@@ -53,36 +64,46 @@ func (graph *Graph) Recustomize() error {
 	for _, viaVertex := range graph.contractionOrder {
 		shortcuts := graph.shortcutsByVia[viaVertex]
 		for _, shortcut := range shortcuts {
-			// Recompute shortcut cost: cost(From->Via) + cost(Via->To)
-			fromViaCost := graph.getEdgeCost(shortcut.From, shortcut.Via)
-			viaToaCost := graph.getEdgeCost(shortcut.Via, shortcut.To)
+			graph.recomputeShortcut(shortcut)
+		}
+	}
+	graph.publishAllWeights()
 
-			if fromViaCost < 0 || viaToaCost < 0 {
-				// Edge not found - should not happen in a valid CH
-				continue
-			}
+	return nil
+}
 
-			newCost := fromViaCost + viaToaCost
-			if newCost != shortcut.Cost {
-				shortcut.Cost = newCost
+// recomputeShortcut Recomputes shortcut cost as cost(From->Via) + cost(Via->To) and updates incident edges.
+// Returns true if cost has been changed.
+func (graph *Graph) recomputeShortcut(shortcut *ShortcutPath) bool {
+	fromViaCost := graph.getEdgeCost(shortcut.From, shortcut.Via)
+	viaToaCost := graph.getEdgeCost(shortcut.Via, shortcut.To)
 
-				// Update incident edges
-				graph.Vertices[shortcut.From].updateOutIncidentEdge(shortcut.To, newCost)
-				graph.Vertices[shortcut.To].updateInIncidentEdge(shortcut.From, newCost)
-			}
-		}
+	if fromViaCost < 0 || viaToaCost < 0 {
+		// Edge not found - should not happen in a valid CH
+		return false
 	}
 
-	return nil
+	newCost := fromViaCost + viaToaCost
+	if newCost == shortcut.Cost {
+		return false
+	}
+	shortcut.Cost = newCost
+
+	// Update incident edges
+	graph.Vertices[shortcut.From].updateOutShortcutEdge(shortcut.To, newCost)
+	graph.Vertices[shortcut.To].updateInShortcutEdge(shortcut.From, newCost)
+	return true
 }
 
 // getEdgeCost Returns the cost of edge from source to target (internal IDs).
+// If there are parallel edges (e.g. initial edge and shortcut) then the cheapest one is taken.
 // Returns -1 if edge is not found.
 func (graph *Graph) getEdgeCost(from, to int64) float64 {
+	cost := -1.0
 	for _, edge := range graph.Vertices[from].outIncidentEdges {
-		if edge.vertexID == to {
-			return edge.weight
+		if edge.vertexID == to && (cost < 0 || edge.weight < cost) {
+			cost = edge.weight
 		}
 	}
-	return -1
+	return cost
 }
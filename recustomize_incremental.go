@@ -0,0 +1,261 @@
+package ch
+
+import (
+	"container/heap"
+
+	"github.com/pkg/errors"
+)
+
+// EdgeKey Edge of graph identified by user's defined IDs of its vertices
+type EdgeKey struct {
+	From int64
+	To   int64
+}
+
+// RecustomizeIncremental Recomputes costs of shortcuts which depend on given changed edges only.
+// It gives the same result as Recustomize(), but its time depends on size of change instead of size of graph.
+//
+// changed - Edges which weights have been modified via UpdateEdgeWeight(..., false) since the last recustomization
+//
+// Changes are propagated upward in hierarchies: shortcuts which use changed edge (see shortcutsByVia) are recomputed,
+// then shortcuts which use changed shortcuts and so on. Shortcuts are processed in contraction order of their Via-vertices.
+// For graph prepared by PrepareCustomizable() (or after RecustomizeTriangles()) lower triangles of arcs are enumerated instead.
+//
+// Returns error if CH has not been prepared yet or if some of edges is not found (nothing is recomputed in that case).
+func (graph *Graph) RecustomizeIncremental(changed []EdgeKey) error {
+	if !graph.chPrepared {
+		return ErrCHNotPrepared
+	}
+	internal := make([]EdgeKey, 0, len(changed))
+	for _, edge := range changed {
+		internalEdge, err := graph.internalEdge(edge)
+		if err != nil {
+			return err
+		}
+		internal = append(internal, internalEdge)
+	}
+	graph.recustomizeIncremental(internal)
+	return nil
+}
+
+// internalEdge Converts user's defined IDs of vertices of edge to library defined ones. Returns error if vertex or edge is not found.
+func (graph *Graph) internalEdge(edge EdgeKey) (EdgeKey, error) {
+	from, ok := graph.mapping[edge.From]
+	if !ok {
+		return EdgeKey{}, errors.Wrapf(ErrVertexNotFound, "Vertex %d", edge.From)
+	}
+	to, ok := graph.mapping[edge.To]
+	if !ok {
+		return EdgeKey{}, errors.Wrapf(ErrVertexNotFound, "Vertex %d", edge.To)
+	}
+	if graph.Vertices[from].findOutIncidentEdge(to) < 0 {
+		return EdgeKey{}, errors.Wrapf(ErrEdgeNotFound, "Edge %d -> %d", edge.From, edge.To)
+	}
+	return EdgeKey{From: from, To: to}, nil
+}
+
+// recustomizeIncremental See RecustomizeIncremental(...). Edges are given by library defined IDs of vertices.
+func (graph *Graph) recustomizeIncremental(changed []EdgeKey) {
+	var affected []EdgeKey
+	if graph.cch != nil {
+		affected = graph.customizeIncremental(changed)
+	} else {
+		affected = graph.recustomizeByVia(changed)
+	}
+	graph.publishChangedWeights(append(affected, changed...))
+}
+
+// recustomizationItem Shortcut (or arc of hierarchies) waiting for recomputation. Items are processed in order of rank.
+type recustomizationItem struct {
+	rank     int64
+	shortcut *ShortcutPath
+	// Arc and its lower ranked vertex (see customizableHierarchy)
+	arc   int64
+	lower int64
+}
+
+type recustomizationHeap []recustomizationItem
+
+func (h recustomizationHeap) Len() int            { return len(h) }
+func (h recustomizationHeap) Less(i, j int) bool  { return h[i].rank < h[j].rank }
+func (h recustomizationHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *recustomizationHeap) Push(x interface{}) { *h = append(*h, x.(recustomizationItem)) }
+func (h *recustomizationHeap) Pop() interface{} {
+	heapSize := len(*h)
+	lastNode := (*h)[heapSize-1]
+	*h = (*h)[0 : heapSize-1]
+	return lastNode
+}
+
+// recustomizeByVia Recomputes shortcuts (with fixed Via-vertex) which depend on changed edges (library defined IDs of vertices)
+//
+// Both parts of shortcut are lower in hierarchies than shortcut itself: their Via-vertices have lower rank. So once shortcuts are processed in order
+// of rank of Via-vertex, parts of shortcut are final when it is recomputed.
+// Returns shortcuts (pairs of library defined IDs of vertices) which costs have been changed.
+func (graph *Graph) recustomizeByVia(changed []EdgeKey) []EdgeKey {
+	affected := make([]EdgeKey, 0)
+	queue := &recustomizationHeap{}
+	queued := make(map[*ShortcutPath]struct{})
+	// enqueueDependents Adds shortcuts which have arc from -> to as one of parts: from -> to -> X (Via = to) or X -> from -> to (Via = from)
+	enqueueDependents := func(from, to int64) {
+		for _, shortcut := range graph.shortcutsByVia[to] {
+			if shortcut.From == from {
+				if _, ok := queued[shortcut]; !ok {
+					queued[shortcut] = struct{}{}
+					heap.Push(queue, recustomizationItem{rank: graph.Vertices[shortcut.Via].orderPos, shortcut: shortcut})
+				}
+			}
+		}
+		for _, shortcut := range graph.shortcutsByVia[from] {
+			if shortcut.To == to {
+				if _, ok := queued[shortcut]; !ok {
+					queued[shortcut] = struct{}{}
+					heap.Push(queue, recustomizationItem{rank: graph.Vertices[shortcut.Via].orderPos, shortcut: shortcut})
+				}
+			}
+		}
+	}
+	for _, edge := range changed {
+		enqueueDependents(edge.From, edge.To)
+	}
+	for queue.Len() != 0 {
+		shortcut := heap.Pop(queue).(recustomizationItem).shortcut
+		if graph.recomputeShortcut(shortcut) {
+			affected = append(affected, EdgeKey{From: shortcut.From, To: shortcut.To})
+			enqueueDependents(shortcut.From, shortcut.To)
+		}
+	}
+	return affected
+}
+
+// customizeIncremental Recomputes arcs of hierarchies (see customize()) which depend on changed edges (library defined IDs of vertices)
+//
+// Arc between v and u (v is lower) depends on arcs of lower triangles (w, v, u) only, so arcs are processed in order of rank of lower ranked vertex.
+// Once costs of arc are changed, arcs of triangles where it is the lower one are recomputed as well.
+// Returns arcs (pairs of library defined IDs of vertices in both directions) which costs have been changed.
+func (graph *Graph) customizeIncremental(changed []EdgeKey) []EdgeKey {
+	cch := graph.cch
+	affected := make([]EdgeKey, 0)
+	cch.buildLowerArcs(graph.contractionOrder)
+	queue := &recustomizationHeap{}
+	queued := make(map[int64]struct{})
+	enqueue := func(lower, arc int64) {
+		if _, ok := queued[arc]; !ok {
+			queued[arc] = struct{}{}
+			heap.Push(queue, recustomizationItem{rank: cch.rank[lower], arc: arc, lower: lower})
+		}
+	}
+	for _, edge := range changed {
+		arc, _ := cch.arcOf(edge.From, edge.To)
+		if arc < 0 {
+			continue
+		}
+		lower := edge.From
+		if cch.rank[edge.To] < cch.rank[lower] {
+			lower = edge.To
+		}
+		enqueue(lower, arc)
+	}
+	for queue.Len() != 0 {
+		item := heap.Pop(queue).(recustomizationItem)
+		if !graph.recomputeArc(item.lower, item.arc) {
+			continue
+		}
+		// Arc (v, u) is the lower one of triangles (v, u, x) and (v, x, u) for every other upper neighbor x of v
+		v, u := item.lower, cch.head[item.arc]
+		affected = append(affected, EdgeKey{From: v, To: u}, EdgeKey{From: u, To: v})
+		for other := cch.firstArc[v]; other < cch.firstArc[v+1]; other++ {
+			x := cch.head[other]
+			if x == u {
+				continue
+			}
+			lower, upper := u, x
+			if cch.rank[upper] < cch.rank[lower] {
+				lower, upper = upper, lower
+			}
+			if arc := cch.findArc(lower, upper); arc >= 0 {
+				enqueue(lower, arc)
+			}
+		}
+	}
+	return affected
+}
+
+// buildLowerArcs Builds index of arcs by their upper vertex (if it has not been built yet). Lower neighbors of every vertex are sorted by rank.
+func (cch *customizableHierarchy) buildLowerArcs(contractionOrder []int64) {
+	if cch.lowerFirst != nil {
+		return
+	}
+	n := len(cch.rank)
+	cch.lowerFirst = make([]int64, n+1)
+	for _, upper := range cch.head {
+		cch.lowerFirst[upper+1]++
+	}
+	for v := 0; v < n; v++ {
+		cch.lowerFirst[v+1] += cch.lowerFirst[v]
+	}
+	cch.lowerTail = make([]int64, len(cch.head))
+	cch.lowerArc = make([]int64, len(cch.head))
+	next := make([]int64, n)
+	copy(next, cch.lowerFirst[:n])
+	for _, w := range contractionOrder {
+		for arc := cch.firstArc[w]; arc < cch.firstArc[w+1]; arc++ {
+			upper := cch.head[arc]
+			cch.lowerTail[next[upper]] = w
+			cch.lowerArc[next[upper]] = arc
+			next[upper]++
+		}
+	}
+}
+
+// recomputeArc Recomputes costs of arc between lower and cch.head[arc] in both directions by initial edges and lower triangles (the same way as customize() does).
+// Applies new costs to graph and returns true if they have been changed.
+func (graph *Graph) recomputeArc(lower, arc int64) bool {
+	cch := graph.cch
+	upper := cch.head[arc]
+	var cost [2]float64
+	var via [2]int64
+	for d := range cost {
+		cost[d] = Infinity
+		via[d] = -1
+	}
+	for _, edge := range graph.Vertices[lower].outIncidentEdges[:cch.originalOutDegree[lower]] {
+		if edge.vertexID == upper && edge.weight < cost[cchUp] {
+			cost[cchUp] = edge.weight
+		}
+	}
+	for _, edge := range graph.Vertices[upper].outIncidentEdges[:cch.originalOutDegree[upper]] {
+		if edge.vertexID == lower && edge.weight < cost[cchDown] {
+			cost[cchDown] = edge.weight
+		}
+	}
+	for k := cch.lowerFirst[lower]; k < cch.lowerFirst[lower+1]; k++ {
+		w, lowerArc := cch.lowerTail[k], cch.lowerArc[k]
+		upperArc := cch.findArc(w, upper)
+		if upperArc < 0 {
+			continue
+		}
+		// lower→w→upper
+		if c := cch.cost[cchDown][lowerArc] + cch.cost[cchUp][upperArc]; c < cost[cchUp] {
+			cost[cchUp] = c
+			via[cchUp] = w
+		}
+		// upper→w→lower
+		if c := cch.cost[cchDown][upperArc] + cch.cost[cchUp][lowerArc]; c < cost[cchDown] {
+			cost[cchDown] = c
+			via[cchDown] = w
+		}
+	}
+
+	changed := false
+	for d := range cost {
+		if cost[d] == cch.cost[d][arc] && via[d] == cch.via[d][arc] {
+			continue
+		}
+		cch.cost[d][arc] = cost[d]
+		cch.via[d][arc] = via[d]
+		graph.applyArc(lower, arc, d)
+		changed = true
+	}
+	return changed
+}
@@ -0,0 +1,132 @@
+package ch
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecustomizeIncremental(t *testing.T) {
+	const size = 20
+	full := generateGridGraph(size)
+	full.PrepareContractionHierarchies()
+	incremental := generateGridGraph(size)
+	incremental.PrepareContractionHierarchies()
+	checkRecustomizeIncremental(t, full, incremental, size*size, 1337)
+}
+
+func TestRecustomizeIncrementalCustomizable(t *testing.T) {
+	const size = 20
+	full := generateGridGraph(size)
+	err := full.PrepareCustomizable()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	incremental := generateGridGraph(size)
+	err = incremental.PrepareCustomizable()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	checkRecustomizeIncremental(t, full, incremental, size*size, 1337)
+	if t.Failed() {
+		return
+	}
+	for source := int64(0); source < size*size; source += 41 {
+		for target := int64(0); target < size*size; target += 3 {
+			expectedCost, _ := incremental.VanillaShortestPath(source, target)
+			cost, _ := incremental.ShortestPath(source, target)
+			if math.Abs(expectedCost-cost) > eps {
+				t.Errorf("Cost of path %d -> %d should be %f, but got %f", source, target, expectedCost, cost)
+				return
+			}
+		}
+	}
+}
+
+func TestRecustomizeIncrementalRandom(t *testing.T) {
+	// Shortcuts of random graphs change their Via-vertices during contraction quite often
+	const verticesNum, edgesNum = 60, 240
+	for seed := int64(0); seed < 40; seed++ {
+		graphs := [2]*Graph{}
+		for i := range graphs {
+			rnd := rand.New(rand.NewSource(seed))
+			graphs[i] = NewGraph()
+			for v := int64(0); v < verticesNum; v++ {
+				graphs[i].CreateVertex(v)
+			}
+			for e := 0; e < edgesNum; e++ {
+				from, to := rnd.Int63n(verticesNum), rnd.Int63n(verticesNum)
+				if from == to {
+					continue
+				}
+				graphs[i].AddEdge(from, to, float64(1+rnd.Intn(20)))
+			}
+			graphs[i].PrepareContractionHierarchies()
+		}
+		checkRecustomizeIncremental(t, graphs[0], graphs[1], verticesNum, seed)
+		if t.Failed() {
+			t.Logf("Seed %d", seed)
+			return
+		}
+	}
+}
+
+func TestRecustomizeIncrementalInvalid(t *testing.T) {
+	g := generateGridGraph(3)
+	err := g.RecustomizeIncremental([]EdgeKey{{From: 0, To: 1}})
+	assert.Equal(t, ErrCHNotPrepared, err)
+
+	g.PrepareContractionHierarchies()
+	err = g.RecustomizeIncremental([]EdgeKey{{From: 0, To: 1}, {From: 0, To: 100}})
+	assert.Equal(t, ErrVertexNotFound, errors.Cause(err))
+	err = g.RecustomizeIncremental([]EdgeKey{{From: 0, To: 1}, {From: 0, To: 8}})
+	assert.Equal(t, ErrEdgeNotFound, errors.Cause(err))
+	err = g.RecustomizeIncremental(nil)
+	assert.NoError(t, err)
+}
+
+// checkRecustomizeIncremental Applies the same updates to both graphs and checks that full and incremental recustomizations give the same shortcuts
+func checkRecustomizeIncremental(t *testing.T, full, incremental *Graph, verticesNum int, seed int64) {
+	rnd := rand.New(rand.NewSource(seed))
+	for round := 0; round < 5; round++ {
+		changed := make([]EdgeKey, 0)
+		for i := 0; i < 10; i++ {
+			from := int64(rnd.Intn(verticesNum))
+			vertexNum, _ := full.FindVertex(from)
+			if len(full.Vertices[vertexNum].outIncidentEdges) == 0 {
+				continue
+			}
+			edge := full.Vertices[vertexNum].outIncidentEdges[rnd.Intn(len(full.Vertices[vertexNum].outIncidentEdges))]
+			if edge.shortcut {
+				continue
+			}
+			to := full.Vertices[edge.vertexID].Label
+			weight := 0.5 + rnd.Float64()*10
+			assert.NoError(t, full.UpdateEdgeWeight(from, to, weight, false))
+			assert.NoError(t, incremental.UpdateEdgeWeight(from, to, weight, false))
+			changed = append(changed, EdgeKey{From: from, To: to})
+		}
+		assert.NoError(t, full.Recustomize())
+		assert.NoError(t, incremental.RecustomizeIncremental(changed))
+
+		assert.Equal(t, full.GetShortcutsNum(), incremental.GetShortcutsNum())
+		for from, shortcuts := range full.shortcuts {
+			for to, expected := range shortcuts {
+				shortcut, ok := incremental.shortcuts[from][to]
+				if !ok {
+					t.Errorf("Shortcut %d -> %d is missing", from, to)
+					return
+				}
+				if shortcut.Via != expected.Via || shortcut.Cost != expected.Cost {
+					t.Errorf("Shortcut %d -> %d should go via %d with cost %f, but got via %d with cost %f", from, to, expected.Via, expected.Cost, shortcut.Via, shortcut.Cost)
+					return
+				}
+			}
+		}
+	}
+}
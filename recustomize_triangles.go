@@ -0,0 +1,188 @@
+package ch
+
+// AffectedPair Pair of vertices which cost (or middle vertex) in hierarchies has been changed by recustomization
+//
+// From - User's defined ID of source vertex
+// To - User's defined ID of target vertex
+// Via - User's defined ID of middle vertex of shortcut (meaningful only if IsShortcut is true, otherwise it is -1)
+// IsShortcut - Pair is connected by shortcut (false if pair is connected by initial edge or if there is no path through lower vertices)
+// PreviousCost - Cost before recustomization (Infinity if there was no path through lower vertices)
+// Cost - Cost after recustomization (Infinity if there is no path through lower vertices)
+type AffectedPair struct {
+	From         int64
+	To           int64
+	Via          int64
+	IsShortcut   bool
+	PreviousCost float64
+	Cost         float64
+}
+
+// RecustomizeTriangles Recomputes costs of all shortcuts based on current edge weights by enumeration of lower triangles.
+//
+// Unlike Recustomize() for graph prepared by PrepareContractionHierarchies(), middle vertex of shortcut is not fixed: every pair of adjacent vertices gets
+// the minimum over initial edges and over all paths through vertex which is lower than both of them and adjacent to both of them.
+// So both decreases and increases of weights are handled: Via of shortcut is updated when the best middle vertex changes,
+// shortcut is removed when initial edge becomes the best one and added when path through lower vertex becomes shorter than initial edge.
+// Once it has been called, Recustomize() uses the same procedure.
+//
+// The switch is permanent: graph stays in customizable mode (as if it has been prepared by PrepareCustomizable()), so RecustomizeIncremental(...) enumerates triangles as well,
+// metrics could be added (see AddMetric(...)) and CSV or binary export returns ErrCustomizableNotExportable.
+//
+// Note: hierarchies prepared with witness searches could lack shortcuts which are needed for new metric (witness path could vanish).
+// Use PrepareCustomizable() if shortest paths must be exact for arbitrary metric.
+//
+// Returns pairs of vertices which cost or middle vertex (used by queries) has been changed.
+// Returns error if CH has not been prepared yet.
+func (graph *Graph) RecustomizeTriangles() ([]AffectedPair, error) {
+	if !graph.chPrepared {
+		return nil, ErrCHNotPrepared
+	}
+	if graph.cch == nil {
+		graph.cch = graph.buildTriangleHierarchy()
+	}
+	return graph.customize(true), nil
+}
+
+// buildTriangleHierarchy Builds hierarchy (see customizableHierarchy) from shortcuts and incident edges of prepared graph.
+//
+// Incident edges of shortcuts (marked by incidentEdge.shortcut) are moved after initial ones, so initial edges are the first originalOutDegree (originalInDegree) ones.
+func (graph *Graph) buildTriangleHierarchy() *customizableHierarchy {
+	n := len(graph.Vertices)
+	cch := &customizableHierarchy{
+		rank:              make([]int64, n),
+		firstArc:          make([]int64, n+1),
+		originalOutDegree: make([]int, n),
+		originalInDegree:  make([]int, n),
+	}
+	for i := range graph.Vertices {
+		cch.rank[i] = graph.Vertices[i].orderPos
+	}
+
+	// Every incoming incident edge is the pair of some outcoming one, so outcoming edges are enough
+	upper := make([][]int64, n)
+	for v := range graph.Vertices {
+		vertexNum := int64(v)
+		for _, edge := range graph.Vertices[v].outIncidentEdges {
+			lower, higher := vertexNum, edge.vertexID
+			if lower == higher {
+				continue
+			}
+			if cch.rank[higher] < cch.rank[lower] {
+				lower, higher = higher, lower
+			}
+			upper[lower] = append(upper[lower], higher)
+		}
+	}
+	for v := range upper {
+		upper[v] = cch.sortByRank(upper[v])
+	}
+	cch.setArcs(upper)
+
+	for v := range graph.Vertices {
+		vertexNum := int64(v)
+		vertex := &graph.Vertices[v]
+		cch.originalOutDegree[v] = partitionIncidentEdges(vertex.outIncidentEdges)
+		for j := cch.originalOutDegree[v]; j < len(vertex.outIncidentEdges); j++ {
+			arc, d := cch.arcOf(vertexNum, vertex.outIncidentEdges[j].vertexID)
+			cch.outPos[d][arc] = j
+		}
+		cch.originalInDegree[v] = partitionIncidentEdges(vertex.inIncidentEdges)
+		for j := cch.originalInDegree[v]; j < len(vertex.inIncidentEdges); j++ {
+			arc, d := cch.arcOf(vertex.inIncidentEdges[j].vertexID, vertexNum)
+			cch.inPos[d][arc] = j
+		}
+	}
+	return cch
+}
+
+// arcOf Returns index and direction of arc which connects given vertices (-1 if there is no such arc)
+func (cch *customizableHierarchy) arcOf(from, to int64) (int64, int) {
+	if from == to {
+		return -1, cchUp
+	}
+	if cch.rank[from] < cch.rank[to] {
+		return cch.findArc(from, to), cchUp
+	}
+	return cch.findArc(to, from), cchDown
+}
+
+// partitionIncidentEdges Moves incident edges of shortcuts (see incidentEdge.shortcut) to the end of list.
+// Order of other edges is kept. Returns number of other (initial) edges.
+func partitionIncidentEdges(edges []incidentEdge) int {
+	tail := make([]incidentEdge, 0)
+	head := 0
+	for i := range edges {
+		if edges[i].shortcut {
+			tail = append(tail, edges[i])
+			continue
+		}
+		edges[head] = edges[i]
+		head++
+	}
+	copy(edges[head:], tail)
+	return head
+}
+
+// queriedArcs Returns costs and middle vertices of arcs which are used by queries now: the cheapest incident edges and shortcuts of graph
+func (graph *Graph) queriedArcs() ([2][]float64, [2][]int64) {
+	cch := graph.cch
+	var cost [2][]float64
+	var via [2][]int64
+	for d := range cost {
+		cost[d] = make([]float64, len(cch.head))
+		via[d] = make([]int64, len(cch.head))
+		for arc := range cost[d] {
+			cost[d][arc] = Infinity
+			via[d][arc] = -1
+		}
+	}
+	for v := range graph.Vertices {
+		vertexNum := int64(v)
+		for _, edge := range graph.Vertices[v].outIncidentEdges {
+			arc, d := cch.arcOf(vertexNum, edge.vertexID)
+			if arc >= 0 && edge.weight < cost[d][arc] {
+				cost[d][arc] = edge.weight
+			}
+		}
+	}
+	for _, shortcuts := range graph.shortcuts {
+		for _, shortcut := range shortcuts {
+			if arc, d := cch.arcOf(shortcut.From, shortcut.To); arc >= 0 {
+				via[d][arc] = shortcut.Via
+			}
+		}
+	}
+	return cost, via
+}
+
+// affectedPairs Returns arcs which cost or middle vertex differs between previous state and the current customization
+func (graph *Graph) affectedPairs(previousCost [2][]float64, previousVia [2][]int64) []AffectedPair {
+	cch := graph.cch
+	affected := []AffectedPair{}
+	for v := range graph.Vertices {
+		for arc := cch.firstArc[v]; arc < cch.firstArc[v+1]; arc++ {
+			for d := range cch.cost {
+				if previousCost[d][arc] == cch.cost[d][arc] && previousVia[d][arc] == cch.via[d][arc] {
+					continue
+				}
+				from, to := int64(v), cch.head[arc]
+				if d == cchDown {
+					from, to = to, from
+				}
+				pair := AffectedPair{
+					From:         graph.Vertices[from].Label,
+					To:           graph.Vertices[to].Label,
+					Via:          -1,
+					PreviousCost: previousCost[d][arc],
+					Cost:         cch.cost[d][arc],
+				}
+				if cch.via[d][arc] >= 0 {
+					pair.Via = graph.Vertices[cch.via[d][arc]].Label
+					pair.IsShortcut = true
+				}
+				affected = append(affected, pair)
+			}
+		}
+	}
+	return affected
+}
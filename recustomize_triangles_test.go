@@ -0,0 +1,207 @@
+package ch
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecustomizeTrianglesChangesVia(t *testing.T) {
+	// Two paths between 2 and 3: through 0 (cost 2) and through 1 (cost 4).
+	// Vertex 1 is contracted after 0, so shortcut 2 -> 3 via 0 is a witness for path through 1 and there is only one shortcut.
+	g := NewGraph()
+	for i := int64(0); i < 4; i++ {
+		g.CreateVertex(i)
+	}
+	g.AddEdge(2, 0, 1)
+	g.AddEdge(0, 3, 1)
+	g.AddEdge(2, 1, 2)
+	g.AddEdge(1, 3, 2)
+	err := g.PrepareWithOrder([]int64{0, 1, 2, 3})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, int64(1), g.GetShortcutsNum())
+
+	err = g.UpdateEdgeWeight(0, 3, 10, false)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	affected, err := g.RecustomizeTriangles()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// Initial edge 0 -> 3 has been already updated by UpdateEdgeWeight(...)
+	assert.Equal(t, []AffectedPair{{From: 2, To: 3, Via: 1, IsShortcut: true, PreviousCost: 2, Cost: 4}}, affected)
+	cost, path := g.ShortestPath(2, 3)
+	assert.Equal(t, 4.0, cost)
+	assert.Equal(t, []int64{2, 1, 3}, path)
+
+	// Nothing is changed
+	affected, err = g.RecustomizeTriangles()
+	assert.NoError(t, err)
+	assert.Len(t, affected, 0)
+
+	// Path through 0 becomes the best one again (plain Recustomize() enumerates triangles too)
+	err = g.UpdateEdgeWeight(2, 1, 20, false)
+	assert.NoError(t, err)
+	err = g.Recustomize()
+	assert.NoError(t, err)
+	cost, path = g.ShortestPath(2, 3)
+	assert.Equal(t, 11.0, cost)
+	assert.Equal(t, []int64{2, 0, 3}, path)
+}
+
+func TestRecustomizeTrianglesNegativeVia(t *testing.T) {
+	// The same graph as in TestRecustomizeTrianglesChangesVia, but middle vertex has user's defined ID -1
+	g := NewGraph()
+	for _, label := range []int64{0, -1, 2, 3} {
+		g.CreateVertex(label)
+	}
+	g.AddEdge(2, 0, 1)
+	g.AddEdge(0, 3, 1)
+	g.AddEdge(2, -1, 2)
+	g.AddEdge(-1, 3, 2)
+	err := g.PrepareWithOrder([]int64{0, -1, 2, 3})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = g.UpdateEdgeWeight(0, 3, 10, false)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	affected, err := g.RecustomizeTriangles()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, []AffectedPair{{From: 2, To: 3, Via: -1, IsShortcut: true, PreviousCost: 2, Cost: 4}}, affected)
+}
+
+func TestRecustomizeTrianglesShortcutRemoved(t *testing.T) {
+	// Initial edge 0 -> 2 is longer than path through 1, so shortcut 0 -> 2 via 1 is added
+	g := NewGraph()
+	for i := int64(0); i < 3; i++ {
+		g.CreateVertex(i)
+	}
+	g.AddEdge(0, 1, 1)
+	g.AddEdge(1, 2, 1)
+	g.AddEdge(0, 2, 5)
+	err := g.PrepareWithOrder([]int64{1, 0, 2})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, int64(1), g.GetShortcutsNum())
+
+	err = g.UpdateEdgeWeight(0, 2, 1.5, false)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	affected, err := g.RecustomizeTriangles()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// Queries have used updated initial edge already, but path has been unpacked via 1
+	assert.Equal(t, []AffectedPair{{From: 0, To: 2, Via: -1, IsShortcut: false, PreviousCost: 1.5, Cost: 1.5}}, affected)
+	assert.Equal(t, int64(0), g.GetShortcutsNum())
+	assert.Len(t, g.shortcutsByVia[1], 0)
+	cost, path := g.ShortestPath(0, 2)
+	assert.Equal(t, 1.5, cost)
+	assert.Equal(t, []int64{0, 2}, path)
+	cost, _ = g.VanillaShortestPath(0, 2)
+	assert.Equal(t, 1.5, cost)
+
+	// Shortcut is restored
+	err = g.UpdateEdgeWeight(0, 2, 5, true)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), g.GetShortcutsNum())
+	cost, path = g.ShortestPath(0, 2)
+	assert.Equal(t, 2.0, cost)
+	assert.Equal(t, []int64{0, 1, 2}, path)
+}
+
+func TestRecustomizeTrianglesGrid(t *testing.T) {
+	const size = 15
+	g := generateGridGraph(size)
+	g.PrepareContractionHierarchies()
+
+	// Metric is the same: nothing should be changed except shortcuts which are dominated by other lower triangles
+	_, err := g.RecustomizeTriangles()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	affected, err := g.RecustomizeTriangles()
+	assert.NoError(t, err)
+	assert.Len(t, affected, 0)
+	for source := int64(0); source < size*size; source += 17 {
+		for target := int64(0); target < size*size; target += 5 {
+			expectedCost, _ := g.VanillaShortestPath(source, target)
+			cost, _ := g.ShortestPath(source, target)
+			if math.Abs(expectedCost-cost) > eps {
+				t.Errorf("Cost of path %d -> %d should be %f, but got %f", source, target, expectedCost, cost)
+				return
+			}
+		}
+	}
+	// Every shortcut is the best path through lower triangles
+	for _, shortcuts := range g.shortcuts {
+		for _, shortcut := range shortcuts {
+			best := Infinity
+			for _, edge := range g.Vertices[shortcut.From].outIncidentEdges {
+				via := edge.vertexID
+				if g.Vertices[via].orderPos >= g.Vertices[shortcut.From].orderPos || g.Vertices[via].orderPos >= g.Vertices[shortcut.To].orderPos {
+					continue
+				}
+				for _, next := range g.Vertices[via].outIncidentEdges {
+					if next.vertexID == shortcut.To && edge.weight+next.weight < best {
+						best = edge.weight + next.weight
+					}
+				}
+			}
+			assert.InDelta(t, best, shortcut.Cost, eps)
+		}
+	}
+}
+
+func TestRecustomizeTrianglesNotPrepared(t *testing.T) {
+	g := generateGridGraph(3)
+	_, err := g.RecustomizeTriangles()
+	assert.Equal(t, ErrCHNotPrepared, err)
+}
+
+func TestRecustomizeTrianglesParallelShortcut(t *testing.T) {
+	// Shortcut 1 -> 2 (via 3) is parallel to initial edge 1 -> 2
+	g := parallelShortcutGraph(t)
+	from, to := g.mapping[1], g.mapping[2]
+	// Incident edge of shortcut is placed before the initial one: it is identified by flag, not by position
+	outcomingEdges := g.Vertices[from].outIncidentEdges
+	for i, j := 0, len(outcomingEdges)-1; i < j; i, j = i+1, j-1 {
+		outcomingEdges[i], outcomingEdges[j] = outcomingEdges[j], outcomingEdges[i]
+	}
+	_, err := g.RecustomizeTriangles()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, g.cch.originalOutDegree[from])
+	assert.Equal(t, 2, g.cch.originalInDegree[to])
+	assert.Equal(t, 10.0, g.originalEdgeCost(from, to))
+
+	assert.NoError(t, g.UpdateEdgeWeight(1, 2, 0.5, true))
+	cost, path := g.ShortestPath(1, 2)
+	assert.Equal(t, 0.5, cost)
+	assert.Equal(t, []int64{1, 2}, path)
+	assert.NoError(t, g.UpdateEdgeWeight(1, 2, 10, true))
+	cost, path = g.ShortestPath(1, 2)
+	assert.Equal(t, 2.0, cost)
+	assert.Equal(t, []int64{1, 3, 2}, path)
+}
@@ -0,0 +1,134 @@
+package ch
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// RPHAST One-to-many query engine for fixed set of target vertices (restricted PHAST). See NewRPHAST(...)
+//
+// Target selection subgraph is built once, so every query is upward search from source followed by linear sweep over selected vertices only.
+// Queries do not modify engine, so it could be used concurrently.
+type RPHAST struct {
+	graph *Graph
+	// Targets - library defined IDs of selected vertices for target vertices
+	targets []int64
+	// Selected vertices in descending order of contraction (library defined IDs) and inverse mapping
+	vertices []int64
+	local    map[int64]int64
+	// Downward arcs which enter selected vertices in CSR form: arcs of i-th vertex are in [firstArc[i], firstArc[i+1])
+	firstArc []int64
+	tails    []int64
+	weights  []float64
+}
+
+// NewRPHAST Builds restricted PHAST engine for given set of target vertices
+//
+// targets - Set of user's definied IDs of target vertices
+//
+// Target selection subgraph consists of vertices visited by backward upward searches in contraction hierarchies from targets
+// (i.e. vertices from which targets could be reached by downward arcs) and downward arcs between them.
+// Weights of arcs are copied, so engine must be built again after edge weights have been updated.
+//
+// Returns error if CH has not been prepared or if some of targets is not found.
+func (graph *Graph) NewRPHAST(targets []int64) (*RPHAST, error) {
+	if !graph.chPrepared {
+		return nil, ErrCHNotPrepared
+	}
+	engine := &RPHAST{
+		graph:   graph,
+		targets: make([]int64, len(targets)),
+		local:   make(map[int64]int64),
+	}
+	stack := make([]int64, 0, len(targets))
+	for i, target := range targets {
+		targetInternal, ok := graph.mapping[target]
+		if !ok {
+			return nil, errors.Wrapf(ErrVertexNotFound, "Target %d", target)
+		}
+		engine.targets[i] = targetInternal
+		if _, ok := engine.local[targetInternal]; !ok {
+			engine.local[targetInternal] = -1
+			stack = append(stack, targetInternal)
+		}
+	}
+	// Upward closure of targets in backward direction
+	for len(stack) != 0 {
+		vertex := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		engine.vertices = append(engine.vertices, vertex)
+		for _, edge := range graph.Vertices[vertex].inIncidentEdges {
+			from := edge.vertexID
+			if graph.Vertices[from].orderPos <= graph.Vertices[vertex].orderPos {
+				continue
+			}
+			if _, ok := engine.local[from]; !ok {
+				engine.local[from] = -1
+				stack = append(stack, from)
+			}
+		}
+	}
+	sort.Slice(engine.vertices, func(i, j int) bool {
+		return graph.Vertices[engine.vertices[i]].orderPos > graph.Vertices[engine.vertices[j]].orderPos
+	})
+	for i, vertex := range engine.vertices {
+		engine.local[vertex] = int64(i)
+	}
+	engine.firstArc = make([]int64, 0, len(engine.vertices)+1)
+	for _, vertex := range engine.vertices {
+		engine.firstArc = append(engine.firstArc, int64(len(engine.tails)))
+		for _, edge := range graph.Vertices[vertex].inIncidentEdges {
+			from := edge.vertexID
+			if graph.Vertices[from].orderPos <= graph.Vertices[vertex].orderPos {
+				continue
+			}
+			engine.tails = append(engine.tails, engine.local[from])
+			engine.weights = append(engine.weights, edge.weight)
+		}
+	}
+	engine.firstArc = append(engine.firstArc, int64(len(engine.tails)))
+	return engine, nil
+}
+
+// GetSelectedVerticesNum Returns number of vertices of target selection subgraph
+func (engine *RPHAST) GetSelectedVerticesNum() int {
+	return len(engine.vertices)
+}
+
+// Query Computes and returns costs of shortest paths from source vertex to every target (in the same order as targets were given to NewRPHAST(...))
+//
+// If source vertex is not found then function returns nil. Cost of unreachable target is '-1.0'
+//
+// source - User's definied ID of source vertex
+func (engine *RPHAST) Query(source int64) []float64 {
+	sourceInternal, ok := engine.graph.mapping[source]
+	if !ok {
+		return nil
+	}
+	costs := make([]float64, len(engine.vertices))
+	for i := range costs {
+		costs[i] = Infinity
+	}
+	upward, _ := engine.graph.upwardSearch(sourceInternal, forward, Infinity)
+	for vertex, cost := range upward {
+		if i, ok := engine.local[vertex]; ok {
+			costs[i] = cost
+		}
+	}
+	for i := range engine.vertices {
+		for arc := engine.firstArc[i]; arc < engine.firstArc[i+1]; arc++ {
+			if alt := costs[engine.tails[arc]] + engine.weights[arc]; alt < costs[i] {
+				costs[i] = alt
+			}
+		}
+	}
+	result := make([]float64, len(engine.targets))
+	for i, target := range engine.targets {
+		result[i] = costs[engine.local[target]]
+		if result[i] == Infinity {
+			result[i] = -1.0
+		}
+	}
+	return result
+}
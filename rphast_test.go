@@ -0,0 +1,45 @@
+package ch
+
+import (
+	"math"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRPHAST(t *testing.T) {
+	const size = 10
+	g := generateGridGraph(size)
+	// Vertex without edges
+	g.CreateVertex(size * size)
+	g.PrepareContractionHierarchies()
+
+	targets := []int64{3, 17, 42, 42, 99, 58, size * size}
+	engine, err := g.NewRPHAST(targets)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, engine.GetSelectedVerticesNum() < len(g.Vertices))
+	for source := int64(0); source < size*size; source += 3 {
+		costs := engine.Query(source)
+		all := g.ShortestPathsOneToAll(source)
+		if !assert.Len(t, costs, len(targets)) {
+			return
+		}
+		for i, target := range targets {
+			targetInternal, _ := g.FindVertex(target)
+			if math.Abs(all[targetInternal]-costs[i]) > eps {
+				t.Errorf("Cost of path %d -> %d should be %f, but got %f", source, target, all[targetInternal], costs[i])
+				return
+			}
+		}
+		assert.Equal(t, -1.0, costs[len(costs)-1])
+	}
+	assert.Nil(t, engine.Query(1000))
+
+	_, err = g.NewRPHAST([]int64{1, 1000})
+	assert.Equal(t, ErrVertexNotFound, errors.Cause(err))
+	_, err = generateGridGraph(3).NewRPHAST([]int64{1})
+	assert.Equal(t, ErrCHNotPrepared, err)
+}
@@ -12,4 +12,6 @@ type ShortcutPath struct {
 	To   int64
 	Via  int64
 	Cost float64
+	// Number of initial edges represented by shortcut (known only for shortcuts created during preparation)
+	originalEdges int
 }
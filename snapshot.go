@@ -0,0 +1,194 @@
+package ch
+
+// MetricSnapshot Immutable flat representation of contraction hierarchies with weights at some moment (see Graph.Snapshot())
+//
+// Queries of QueryPool take the current snapshot once and run on it till the end, so recustomization never changes weights under running query.
+type MetricSnapshot struct {
+	version uint64
+	query   *queryGraph
+}
+
+// Version Returns version of snapshot. Every published snapshot of graph (or of named metric) gets version greater by one than the previous one
+func (snapshot *MetricSnapshot) Version() uint64 {
+	return snapshot.version
+}
+
+// NewQueryPool Creates QueryPool which runs queries on this snapshot only (it does not switch to snapshots published later). See Graph.NewQueryPool()
+func (snapshot *MetricSnapshot) NewQueryPool() *QueryPool {
+	return newQueryPool(nil, snapshot.query)
+}
+
+// Snapshot Returns current snapshot of contraction hierarchies which is used by QueryPool created by NewQueryPool().
+// The first snapshot is built on demand: by this function or by the first query of QueryPool.
+//
+// Once snapshot exists, new one is published atomically after every change of shortcuts costs: Recustomize(), RecustomizeTriangles(), RecustomizeIncremental(...),
+// UpdateEdgeWeights(...) and UpdateEdgeWeight(..., true). UpdateEdgeWeight(..., false) does not publish snapshot: updated weight is used by QueryPool after recustomization only.
+// Queries which are running already finish with the previous snapshot, while new ones use the published one. Reading of current snapshot is lock-free.
+func (graph *Graph) Snapshot() *MetricSnapshot {
+	if snapshot := graph.loadSnapshot(); snapshot != nil {
+		return snapshot
+	}
+	graph.snapshotMu.Lock()
+	defer graph.snapshotMu.Unlock()
+	// Snapshot could be built while waiting for lock
+	if snapshot := graph.loadSnapshot(); snapshot != nil {
+		return snapshot
+	}
+	snapshot := &MetricSnapshot{version: 1, query: graph.buildQueryGraph()}
+	graph.snapshot.Store(snapshot)
+	graph.snapshotTopologyChanged = false
+	return snapshot
+}
+
+// loadSnapshot Returns current snapshot (nil if it has not been built yet)
+func (graph *Graph) loadSnapshot() *MetricSnapshot {
+	snapshot, _ := graph.snapshot.Load().(*MetricSnapshot)
+	return snapshot
+}
+
+// publishSnapshot Builds snapshot of current state of graph and replaces the current one. Should be called whenever hierarchies are (re)built.
+// If weights are changed only then publishAllWeights() or publishChangedWeights(...) should be used: they don't rebuild topology.
+// Nothing is done if there is no snapshot yet: it is built lazily then.
+func (graph *Graph) publishSnapshot() {
+	graph.snapshotMu.Lock()
+	defer graph.snapshotMu.Unlock()
+	previous := graph.loadSnapshot()
+	if previous == nil {
+		return
+	}
+	graph.snapshot.Store(&MetricSnapshot{version: previous.version + 1, query: graph.buildQueryGraph()})
+	graph.snapshotTopologyChanged = false
+}
+
+// publishAllWeights Publishes snapshot with current costs of all arcs. See publishWeights(...)
+func (graph *Graph) publishAllWeights() {
+	graph.publishWeights(graph.refreshArcs)
+}
+
+// publishChangedWeights Publishes snapshot where costs of arcs between given pairs of vertices (library defined IDs) are updated only. See publishWeights(...)
+func (graph *Graph) publishChangedWeights(changed []EdgeKey) {
+	graph.publishWeights(func(q *queryGraph) bool {
+		for _, edge := range changed {
+			if !graph.patchArc(q, edge.From, edge.To) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// publishWeights Publishes snapshot which shares topology (labels, offsets and targets of arcs) with the current one: weights and Via-vertices are copied and then patched.
+// Snapshot is rebuilt entirely if topology has been changed since the current one was published: order of vertices is changed or patch meets arc which is missing in snapshot
+// (customization of hierarchies adds incident edges for arcs which get middle vertex for the first time).
+// Nothing is done if there is no snapshot yet: it is built lazily then.
+func (graph *Graph) publishWeights(patch func(q *queryGraph) bool) {
+	if graph.snapshotTopologyChanged {
+		graph.publishSnapshot()
+		return
+	}
+	graph.snapshotMu.Lock()
+	defer graph.snapshotMu.Unlock()
+	previous := graph.loadSnapshot()
+	if previous == nil {
+		return
+	}
+	// Previous snapshot could be used by running queries, so arrays which are patched are copied
+	q := *previous.query
+	for d := forward; d < directionsCount; d++ {
+		q.weights[d] = append([]float64(nil), previous.query.weights[d]...)
+		q.via[d] = append([]int64(nil), previous.query.via[d]...)
+	}
+	if !patch(&q) {
+		q = *graph.buildQueryGraph()
+	}
+	graph.snapshot.Store(&MetricSnapshot{version: previous.version + 1, query: &q})
+}
+
+// refreshArcs Stores current costs and Via-vertices of all arcs in flat representation of graph. The same as buildQueryGraph() does, but topology is not rebuilt.
+// Returns false if some arc is missing in flat representation.
+func (graph *Graph) refreshArcs(q *queryGraph) bool {
+	// Reusable buffer: target -> index of arc
+	arcIdx := make(map[int64]int64)
+	for d := forward; d < directionsCount; d++ {
+		for i := range graph.Vertices {
+			vertex := &graph.Vertices[i]
+			var vertexList []incidentEdge
+			if d == forward {
+				vertexList = vertex.outIncidentEdges
+			} else {
+				vertexList = vertex.inIncidentEdges
+			}
+			for k := range arcIdx {
+				delete(arcIdx, k)
+			}
+			for e := q.offsets[d][i]; e < q.offsets[d][i+1]; e++ {
+				arcIdx[q.targets[d][e]] = e
+				q.weights[d][e] = Infinity
+				q.via[d][e] = -1
+			}
+			for _, edge := range vertexList {
+				if vertex.orderPos >= graph.Vertices[edge.vertexID].orderPos {
+					continue
+				}
+				idx, ok := arcIdx[edge.vertexID]
+				if !ok {
+					return false
+				}
+				if edge.weight < q.weights[d][idx] {
+					from, to := int64(i), edge.vertexID
+					if d == backward {
+						from, to = to, from
+					}
+					q.weights[d][idx] = edge.weight
+					q.via[d][idx] = graph.incidentEdgeVia(from, to, edge)
+				}
+			}
+		}
+	}
+	return true
+}
+
+// patchArc Stores current cost and Via-vertex of arc between from and to (library defined IDs) in flat representation of graph.
+// Returns false if there is no such arc in flat representation.
+func (graph *Graph) patchArc(q *queryGraph, from, to int64) bool {
+	if graph.Vertices[from].orderPos == graph.Vertices[to].orderPos {
+		// Loops are not stored
+		return true
+	}
+	// Arc is stored as forward arc of its tail or as backward arc of its head: the one which is lower in hierarchies
+	d, lower, upper := forward, from, to
+	if graph.Vertices[from].orderPos > graph.Vertices[to].orderPos {
+		d, lower, upper = backward, to, from
+	}
+	for e := q.offsets[d][lower]; e < q.offsets[d][lower+1]; e++ {
+		if q.targets[d][e] == upper {
+			q.weights[d][e], q.via[d][e] = graph.cheapestArc(from, to)
+			return true
+		}
+	}
+	return false
+}
+
+// cheapestArc Returns cost and Via-vertex (-1 for initial edge) of the cheapest arc from -> to (library defined IDs) among parallel ones
+func (graph *Graph) cheapestArc(from, to int64) (float64, int64) {
+	cost, via := Infinity, int64(-1)
+	for _, edge := range graph.Vertices[from].outIncidentEdges {
+		if edge.vertexID == to && edge.weight < cost {
+			cost, via = edge.weight, graph.incidentEdgeVia(from, to, edge)
+		}
+	}
+	return cost, via
+}
+
+// arcViaVertex Returns Via-vertex of shortcut from -> to (library defined IDs) or -1 if there is no such shortcut
+func (graph *Graph) arcViaVertex(from, to int64) int64 {
+	if shortcut, ok := graph.shortcuts[from][to]; ok {
+		return shortcut.Via
+	}
+	return -1
+}
+
+// sharedQueryGraph Returns flat representation of current snapshot of graph
+func (graph *Graph) sharedQueryGraph() *queryGraph {
+	return graph.Snapshot().query
+}
@@ -0,0 +1,183 @@
+package ch
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotVersions(t *testing.T) {
+	g := generateGridGraph(5)
+	g.PrepareContractionHierarchies()
+	pool := g.NewQueryPool()
+	first := g.Snapshot()
+	assert.Equal(t, uint64(1), first.Version())
+	pinned := first.NewQueryPool()
+	costBefore, _ := pool.ShortestPath(0, 24)
+
+	// Weight is not published without recustomization
+	err := g.UpdateEdgeWeight(0, 1, 100, false)
+	assert.NoError(t, err)
+	err = g.UpdateEdgeWeight(0, 5, 100, false)
+	assert.NoError(t, err)
+	assert.Equal(t, first, g.Snapshot())
+	cost, _ := pool.ShortestPath(0, 24)
+	assert.Equal(t, costBefore, cost)
+
+	err = g.Recustomize()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), g.Snapshot().Version())
+	expectedCost, _ := g.ShortestPath(0, 24)
+	assert.NotEqual(t, costBefore, expectedCost)
+	cost, _ = pool.ShortestPath(0, 24)
+	assert.Equal(t, expectedCost, cost)
+	// Pinned pool keeps the first snapshot
+	cost, _ = pinned.ShortestPath(0, 24)
+	assert.Equal(t, costBefore, cost)
+
+	err = g.UpdateEdgeWeights([]EdgeWeightUpdate{{From: 0, To: 1, Weight: 1}})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), g.Snapshot().Version())
+}
+
+func TestSnapshotConcurrentRecustomize(t *testing.T) {
+	const size = 15
+	g := generateGridGraph(size)
+	g.PrepareContractionHierarchies()
+	pool := g.NewQueryPool()
+
+	// Cost of path alternates between two values: for cheap and for expensive first row of grid
+	source, target := int64(0), int64(size*size-1)
+	weights := [2]float64{1, 50}
+	row := make([]EdgeWeightUpdate, size-1)
+	costs := make(map[float64]struct{})
+	for _, weight := range weights {
+		for x := range row {
+			row[x] = EdgeWeightUpdate{From: int64(x), To: int64(x + 1), Weight: weight}
+		}
+		err := g.UpdateEdgeWeights(row)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		cost, _ := g.ShortestPath(source, target)
+		costs[cost] = struct{}{}
+	}
+	assert.Len(t, costs, 2)
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	errs := make(chan float64, 1)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				cost, _ := pool.ShortestPath(source, target)
+				if _, ok := costs[cost]; !ok {
+					select {
+					case errs <- cost:
+					default:
+					}
+					return
+				}
+			}
+		}()
+	}
+	for round := 0; round < 20; round++ {
+		for i := range row {
+			row[i].Weight = weights[round%2]
+		}
+		err := g.UpdateEdgeWeights(row)
+		if err != nil {
+			t.Error(err)
+			break
+		}
+	}
+	close(done)
+	wg.Wait()
+	select {
+	case cost := <-errs:
+		t.Errorf("Cost of path should be one of %v, but got %f", costs, cost)
+	default:
+	}
+	cost, _ := pool.ShortestPath(source, target)
+	expectedCost, _ := g.ShortestPath(source, target)
+	assert.InDelta(t, expectedCost, cost, eps)
+}
+
+func TestSnapshotSharedTopology(t *testing.T) {
+	const size = 10
+	for _, customizable := range []bool{false, true} {
+		g := generateGridGraph(size)
+		if customizable {
+			if !assert.NoError(t, g.PrepareCustomizable()) {
+				return
+			}
+		} else {
+			g.PrepareContractionHierarchies()
+		}
+		first := g.Snapshot().query
+		rnd := rand.New(rand.NewSource(1337))
+		for round := 0; round < 6; round++ {
+			updates := make([]EdgeWeightUpdate, 0)
+			for i := 0; i < 5; i++ {
+				from := int64(rnd.Intn(size * size))
+				for _, edge := range g.originalOutIncidentEdges(from) {
+					updates = append(updates, EdgeWeightUpdate{From: g.Vertices[from].Label, To: g.Vertices[edge.vertexID].Label, Weight: 0.5 + rnd.Float64()*10})
+				}
+			}
+			if round%2 == 0 {
+				assert.NoError(t, g.UpdateEdgeWeights(updates))
+			} else {
+				for _, update := range updates {
+					assert.NoError(t, g.UpdateEdgeWeight(update.From, update.To, update.Weight, false))
+				}
+				assert.NoError(t, g.Recustomize())
+			}
+			q := g.Snapshot().query
+			expected := g.buildQueryGraph()
+			for d := forward; d < directionsCount; d++ {
+				// Topology is shared with the first snapshot while weights are copied
+				assert.True(t, &first.offsets[d][0] == &q.offsets[d][0])
+				assert.True(t, &first.targets[d][0] == &q.targets[d][0])
+				assert.False(t, &first.weights[d][0] == &q.weights[d][0])
+				assert.Equal(t, expected.targets[d], q.targets[d])
+				assert.Equal(t, expected.weights[d], q.weights[d])
+				assert.Equal(t, expected.via[d], q.via[d])
+			}
+		}
+	}
+}
+
+func TestSnapshotParallelShortcut(t *testing.T) {
+	// Initial edge 1 -> 2 and parallel shortcut 1 -> 2 (via 3) take turns in being the cheapest arc
+	g := parallelShortcutGraph(t)
+	pool := g.NewQueryPool()
+	cost, path := pool.ShortestPath(1, 2)
+	assert.Equal(t, 2.0, cost)
+	assert.Equal(t, []int64{1, 3, 2}, path)
+
+	// All weights are refreshed
+	assert.NoError(t, g.UpdateEdgeWeight(1, 2, 0.5, true))
+	cost, path = pool.ShortestPath(1, 2)
+	assert.Equal(t, 0.5, cost)
+	assert.Equal(t, []int64{1, 2}, path)
+
+	// Only changed arcs are patched
+	assert.NoError(t, g.UpdateEdgeWeights([]EdgeWeightUpdate{{From: 1, To: 2, Weight: 0.25}}))
+	cost, path = pool.ShortestPath(1, 2)
+	assert.Equal(t, 0.25, cost)
+	assert.Equal(t, []int64{1, 2}, path)
+	assert.NoError(t, g.UpdateEdgeWeights([]EdgeWeightUpdate{{From: 1, To: 2, Weight: 20}}))
+	cost, path = pool.ShortestPath(1, 2)
+	assert.Equal(t, 2.0, cost)
+	assert.Equal(t, []int64{1, 3, 2}, path)
+}
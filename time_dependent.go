@@ -0,0 +1,184 @@
+package ch
+
+import (
+	"container/heap"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// TravelTimePoint Breakpoint of piecewise-linear travel time function
+//
+// Time - Departure time from the first vertex of edge
+// Duration - Travel time along edge for given departure time
+type TravelTimePoint struct {
+	Time     float64
+	Duration float64
+}
+
+// TravelTimeFunction Piecewise-linear travel time function of edge: travel time depends on departure time.
+// Travel time is linearly interpolated between breakpoints and stays constant before the first breakpoint and after the last one.
+//
+// Function has the FIFO property: departing later never means arriving earlier.
+type TravelTimeFunction struct {
+	points []TravelTimePoint
+}
+
+// NewTravelTimeFunction Creates piecewise-linear travel time function
+//
+// points - Breakpoints sorted by Time (strictly increasing). Durations must be non-negative.
+//
+// Returns error if there are no breakpoints, if they are not sorted or if function violates FIFO property: Time+Duration must not decrease.
+func NewTravelTimeFunction(points []TravelTimePoint) (*TravelTimeFunction, error) {
+	if len(points) == 0 {
+		return nil, errors.Wrap(ErrInvalidTravelTimeFunction, "No breakpoints")
+	}
+	for i, point := range points {
+		if point.Duration < 0 {
+			return nil, errors.Wrapf(ErrInvalidTravelTimeFunction, "Breakpoint #%d has negative duration", i)
+		}
+		if i == 0 {
+			continue
+		}
+		previous := points[i-1]
+		if point.Time <= previous.Time {
+			return nil, errors.Wrapf(ErrInvalidTravelTimeFunction, "Breakpoint #%d is not sorted by time", i)
+		}
+		if point.Time+point.Duration < previous.Time+previous.Duration {
+			return nil, errors.Wrapf(ErrTravelTimeNotFIFO, "Breakpoint #%d", i)
+		}
+	}
+	f := &TravelTimeFunction{
+		points: make([]TravelTimePoint, len(points)),
+	}
+	copy(f.points, points)
+	return f, nil
+}
+
+// Eval Returns travel time for given departure time
+func (f *TravelTimeFunction) Eval(departure float64) float64 {
+	i := sort.Search(len(f.points), func(i int) bool { return f.points[i].Time >= departure })
+	if i == 0 {
+		return f.points[0].Duration
+	}
+	if i == len(f.points) {
+		return f.points[i-1].Duration
+	}
+	left, right := f.points[i-1], f.points[i]
+	return left.Duration + (right.Duration-left.Duration)*(departure-left.Time)/(right.Time-left.Time)
+}
+
+// Min Returns the minimum travel time over all departure times (lower bound of function)
+func (f *TravelTimeFunction) Min() float64 {
+	min := f.points[0].Duration
+	for _, point := range f.points[1:] {
+		if point.Duration < min {
+			min = point.Duration
+		}
+	}
+	return min
+}
+
+// AddTimeDependentEdge Adds new edge with travel time depending on departure time
+//
+// from - User's definied ID of first vertex of edge
+// to - User's definied ID of last vertex of edge
+// travelTime - Travel time function of edge
+//
+// Static weight of edge (used by contraction hierarchies and by every query except ShortestPathAtTime(...)) is the minimum travel time of function.
+// Function replaces weights of all edges from -> to in ShortestPathAtTime(...).
+//
+// Returns error if graph is frozen, if travel time function is not provided or if some of vertices is not found.
+func (graph *Graph) AddTimeDependentEdge(from, to int64, travelTime *TravelTimeFunction) error {
+	if graph.frozen {
+		return ErrGraphIsFrozen
+	}
+	if travelTime == nil {
+		return errors.Wrapf(ErrInvalidTravelTimeFunction, "No travel time function for edge %d -> %d", from, to)
+	}
+	fromInternal, ok := graph.mapping[from]
+	if !ok {
+		return errors.Wrapf(ErrVertexNotFound, "Vertex %d", from)
+	}
+	toInternal, ok := graph.mapping[to]
+	if !ok {
+		return errors.Wrapf(ErrVertexNotFound, "Vertex %d", to)
+	}
+	graph.edgesNum++
+	graph.addEdge(fromInternal, toInternal, travelTime.Min())
+	if graph.travelTimes == nil {
+		graph.travelTimes = make(map[int64]map[int64]*TravelTimeFunction)
+	}
+	if _, ok := graph.travelTimes[fromInternal]; !ok {
+		graph.travelTimes[fromInternal] = make(map[int64]*TravelTimeFunction)
+	}
+	graph.travelTimes[fromInternal][toInternal] = travelTime
+	return nil
+}
+
+// ShortestPathAtTime Computes and returns the fastest path and it's travel time for given departure time (time-dependent Dijkstra's algorithm)
+//
+// If there are some errors then function returns '-1.0' as cost and nil as shortest path
+//
+// source - User's definied ID of source vertex
+// target - User's definied ID of target vertex
+// departure - Departure time from source vertex
+//
+// Edges added by AddTimeDependentEdge(...) are traversed with travel time of their functions at arrival time to their first vertex, other edges - with their weights.
+// Since every function has FIFO property, waiting at vertices never helps and the first arrival at vertex is the best one (as in VanillaShortestPath(...)).
+func (graph *Graph) ShortestPathAtTime(source, target int64, departure float64) (float64, []int64) {
+	if source == target {
+		return 0, []int64{source}
+	}
+	var ok bool
+	if source, ok = graph.mapping[source]; !ok {
+		return -1.0, nil
+	}
+	if target, ok = graph.mapping[target]; !ok {
+		return -1.0, nil
+	}
+
+	arrival := make([]float64, len(graph.Vertices))
+	for i := range arrival {
+		arrival[i] = Infinity
+	}
+	prev := make(map[int64]int64)
+	arrival[source] = departure
+	queue := &minHeap{}
+	queue.add_with_priority(source, departure)
+	for queue.Len() != 0 {
+		u := heap.Pop(queue).(*minHeapVertex)
+		if u.distance > arrival[u.id] {
+			// Outdated item of queue
+			continue
+		}
+		if u.id == target {
+			break
+		}
+		for _, edge := range graph.originalOutIncidentEdges(u.id) {
+			neighbor := edge.vertexID
+			cost := edge.weight
+			if travelTime, ok := graph.travelTimes[u.id][neighbor]; ok {
+				cost = travelTime.Eval(u.distance)
+			}
+			if alt := u.distance + cost; alt < arrival[neighbor] {
+				arrival[neighbor] = alt
+				prev[neighbor] = u.id
+				queue.add_with_priority(neighbor, alt)
+			}
+		}
+	}
+
+	if arrival[target] == Infinity {
+		return -1.0, nil
+	}
+	path := []int64{graph.Vertices[target].Label}
+	for u := target; u != source; {
+		u = prev[u]
+		path = append(path, graph.Vertices[u].Label)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return arrival[target] - departure, path
+}
@@ -0,0 +1,134 @@
+package ch
+
+import (
+	"math"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTravelTimeFunction(t *testing.T) {
+	f, err := NewTravelTimeFunction([]TravelTimePoint{{Time: 10, Duration: 2}, {Time: 20, Duration: 8}, {Time: 30, Duration: 4}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, 2.0, f.Eval(0))
+	assert.Equal(t, 2.0, f.Eval(10))
+	assert.Equal(t, 5.0, f.Eval(15))
+	assert.Equal(t, 6.0, f.Eval(25))
+	assert.Equal(t, 4.0, f.Eval(100))
+	assert.Equal(t, 2.0, f.Min())
+
+	_, err = NewTravelTimeFunction(nil)
+	assert.Equal(t, ErrInvalidTravelTimeFunction, errors.Cause(err))
+	_, err = NewTravelTimeFunction([]TravelTimePoint{{Time: 10, Duration: 2}, {Time: 10, Duration: 3}})
+	assert.Equal(t, ErrInvalidTravelTimeFunction, errors.Cause(err))
+	_, err = NewTravelTimeFunction([]TravelTimePoint{{Time: 10, Duration: -1}})
+	assert.Equal(t, ErrInvalidTravelTimeFunction, errors.Cause(err))
+	// Departing at 11 means arriving at 12, but departing at 10 means arriving at 30
+	_, err = NewTravelTimeFunction([]TravelTimePoint{{Time: 10, Duration: 20}, {Time: 11, Duration: 1}})
+	assert.Equal(t, ErrTravelTimeNotFIFO, errors.Cause(err))
+}
+
+func TestShortestPathAtTime(t *testing.T) {
+	// Road 0 -> 1 -> 3 is congested in the middle of period [10; 30], road 0 -> 2 -> 3 is static
+	g := NewGraph()
+	for i := int64(0); i < 4; i++ {
+		g.CreateVertex(i)
+	}
+	congested, err := NewTravelTimeFunction([]TravelTimePoint{{Time: 10, Duration: 2}, {Time: 20, Duration: 12}, {Time: 30, Duration: 2}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.NoError(t, g.AddTimeDependentEdge(0, 1, congested))
+	g.AddEdge(1, 3, 1)
+	g.AddEdge(0, 2, 4)
+	g.AddEdge(2, 3, 3)
+	err = g.AddTimeDependentEdge(0, 100, congested)
+	assert.Equal(t, ErrVertexNotFound, errors.Cause(err))
+	err = g.AddTimeDependentEdge(0, 3, nil)
+	assert.Equal(t, ErrInvalidTravelTimeFunction, errors.Cause(err))
+
+	cost, path := g.ShortestPathAtTime(0, 3, 0)
+	assert.Equal(t, 3.0, cost)
+	assert.Equal(t, []int64{0, 1, 3}, path)
+	cost, path = g.ShortestPathAtTime(0, 3, 20)
+	assert.Equal(t, 7.0, cost)
+	assert.Equal(t, []int64{0, 2, 3}, path)
+	cost, path = g.ShortestPathAtTime(0, 3, 12)
+	assert.Equal(t, 5.0, cost)
+	assert.Equal(t, []int64{0, 1, 3}, path)
+
+	// Static weight is the lower bound of function
+	cost, _ = g.VanillaShortestPath(0, 3)
+	assert.Equal(t, 3.0, cost)
+
+	cost, path = g.ShortestPathAtTime(3, 0, 0)
+	assert.Equal(t, -1.0, cost)
+	assert.Nil(t, path)
+	cost, path = g.ShortestPathAtTime(0, 100, 0)
+	assert.Equal(t, -1.0, cost)
+	assert.Nil(t, path)
+}
+
+func TestShortestPathAtTimeParallelShortcut(t *testing.T) {
+	// Shortcut 1 -> 3 (via 2) is parallel to initial edge 1 -> 3, but edge 1 -> 2 is congested since 10
+	g := NewGraph()
+	for i := int64(1); i <= 3; i++ {
+		g.CreateVertex(i)
+	}
+	congested, err := NewTravelTimeFunction([]TravelTimePoint{{Time: 0, Duration: 1}, {Time: 10, Duration: 100}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.NoError(t, g.AddTimeDependentEdge(1, 2, congested))
+	g.AddEdge(2, 3, 1)
+	g.AddEdge(1, 3, 5)
+	if !assert.NoError(t, g.PrepareWithOrder([]int64{2, 1, 3})) {
+		return
+	}
+	if !assert.Equal(t, int64(1), g.GetShortcutsNum()) {
+		return
+	}
+
+	cost, path := g.ShortestPathAtTime(1, 3, 0)
+	assert.Equal(t, 2.0, cost)
+	assert.Equal(t, []int64{1, 2, 3}, path)
+	cost, path = g.ShortestPathAtTime(1, 3, 20)
+	assert.Equal(t, 5.0, cost)
+	assert.Equal(t, []int64{1, 3}, path)
+}
+
+func TestShortestPathAtTimeConstant(t *testing.T) {
+	// Constant functions give the same paths as static weights
+	const size = 10
+	expected := generateGridGraph(size)
+	g := NewGraph()
+	for v := range expected.Vertices {
+		g.CreateVertex(expected.Vertices[v].Label)
+	}
+	for v := range expected.Vertices {
+		for _, edge := range expected.Vertices[v].outIncidentEdges {
+			f, err := NewTravelTimeFunction([]TravelTimePoint{{Time: 0, Duration: edge.weight}})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			assert.NoError(t, g.AddTimeDependentEdge(expected.Vertices[v].Label, expected.Vertices[edge.vertexID].Label, f))
+		}
+	}
+	for source := int64(0); source < size*size; source += 7 {
+		for target := int64(0); target < size*size; target += 3 {
+			expectedCost, _ := expected.VanillaShortestPath(source, target)
+			cost, _ := g.ShortestPathAtTime(source, target, 100)
+			if math.Abs(expectedCost-cost) > eps {
+				t.Errorf("Cost of path %d -> %d should be %f, but got %f", source, target, expectedCost, cost)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package ch
+
+import (
+	"container/heap"
+
+	"github.com/pkg/errors"
+)
+
+// turnKey Pair of edges which form turn at some vertex: incoming edge from -> via and outcoming edge via -> to (library defined IDs of vertices)
+type turnKey struct {
+	from int64
+	to   int64
+}
+
+// AddTurnCost Sets cost of turn from edge fromVertex -> viaVertex to edge viaVertex -> toVertex (e.g. penalty for U-turn or for left turn across traffic).
+// Costs are stored in table of via vertex. Turns which are not in table are free.
+//
+// fromVertex - User's definied ID of source vertex of incoming edge
+// viaVertex - User's definied ID of vertex where turn is made
+// toVertex - User's definied ID of target vertex of outcoming edge
+// cost - Non-negative cost of turn (Infinity forbids turn). Previous cost of the same turn is replaced
+//
+// Costs are used by VanillaTurnCostShortestPath(...) and by edge-based contraction hierarchies (see PrepareEdgeBased(...)).
+//
+// Returns error if graph is frozen, if cost is negative or if some of vertices or edges is not found.
+func (graph *Graph) AddTurnCost(fromVertex, viaVertex, toVertex int64, cost float64) error {
+	if graph.frozen {
+		return ErrGraphIsFrozen
+	}
+	if cost < 0 {
+		return errors.Wrapf(ErrInvalidTurnCost, "Turn %d -> %d -> %d", fromVertex, viaVertex, toVertex)
+	}
+	incoming, err := graph.internalEdge(EdgeKey{From: fromVertex, To: viaVertex})
+	if err != nil {
+		return err
+	}
+	outcoming, err := graph.internalEdge(EdgeKey{From: viaVertex, To: toVertex})
+	if err != nil {
+		return err
+	}
+	if graph.turnCosts == nil {
+		graph.turnCosts = make(map[int64]map[turnKey]float64)
+	}
+	if _, ok := graph.turnCosts[incoming.To]; !ok {
+		graph.turnCosts[incoming.To] = make(map[turnKey]float64)
+	}
+	graph.turnCosts[incoming.To][turnKey{from: incoming.From, to: outcoming.To}] = cost
+	return nil
+}
+
+// turnCost Returns cost of turn from -> via -> to (library defined IDs of vertices): cost from table of via vertex plus cost given by function (could be nil)
+func (graph *Graph) turnCost(from, via, to int64, turnCost TurnCostFunc) float64 {
+	cost := graph.turnCosts[via][turnKey{from: from, to: to}]
+	if turnCost != nil {
+		cost += turnCost(graph.Vertices[from].Label, graph.Vertices[via].Label, graph.Vertices[to].Label)
+	}
+	return cost
+}
+
+// VanillaTurnCostShortestPath Computes and returns shortest path and it's cost with turn costs and turn restrictions (vanilla Dijkstra's algorithm on edges)
+//
+// If there are some errors then function returns '-1.0' as cost and nil as shortest path
+//
+// source - User's definied ID of source vertex
+// target - User's definied ID of target vertex
+//
+// Cost of path is the sum of weights of its edges and costs of its turns (see AddTurnCost(...)).
+// Every restriction added by AddTurnRestriction(...) or AddMultiViaTurnRestriction(...) is honored. Results are the same as for PrepareEdgeBased(nil).
+func (graph *Graph) VanillaTurnCostShortestPath(source, target int64) (float64, []int64) {
+	if source == target {
+		return 0, []int64{source}
+	}
+	var ok bool
+	if source, ok = graph.mapping[source]; !ok {
+		return -1.0, nil
+	}
+	if target, ok = graph.mapping[target]; !ok {
+		return -1.0, nil
+	}
+
+	// States are edges of graph (see turnAutomaton)
+	automaton := graph.buildTurnAutomaton()
+	distance := make([]float64, len(automaton.tail))
+	for i := range distance {
+		distance[i] = Infinity
+	}
+	prev := make(map[int64]int64)
+	queue := &minHeap{}
+	for _, v := range automaton.neighbors[source] {
+		state := automaton.plain[source][v]
+		distance[state] = automaton.weights[source][v]
+		queue.add_with_priority(state, distance[state])
+	}
+	last := int64(-1)
+	for queue.Len() != 0 {
+		item := heap.Pop(queue).(*minHeapVertex)
+		state := item.id
+		if item.distance > distance[state] {
+			// Outdated item of queue
+			continue
+		}
+		u, v := automaton.tail[state], automaton.head[state]
+		if v == target {
+			last = state
+			break
+		}
+		for _, x := range automaton.neighbors[v] {
+			next := automaton.next(state, x)
+			if automaton.banned[next] {
+				continue
+			}
+			alt := item.distance + automaton.weights[v][x] + graph.turnCost(u, v, x, nil)
+			if alt < distance[next] {
+				distance[next] = alt
+				prev[next] = state
+				queue.add_with_priority(next, alt)
+			}
+		}
+	}
+	if last < 0 {
+		return -1.0, nil
+	}
+
+	path := []int64{graph.Vertices[target].Label}
+	for state := last; ; {
+		path = append(path, graph.Vertices[automaton.tail[state]].Label)
+		previous, ok := prev[state]
+		if !ok {
+			break
+		}
+		state = previous
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return distance[last], path
+}
@@ -0,0 +1,118 @@
+package ch
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTurnCosts(t *testing.T) {
+	// Straight road 0 -> 1 -> 2 with left turn at 1 to the road 1 -> 3 -> 4, and detour 2 -> 5 -> 4
+	g := NewGraph()
+	for i := int64(0); i < 6; i++ {
+		g.CreateVertex(i)
+	}
+	g.AddEdge(0, 1, 1)
+	g.AddEdge(1, 2, 1)
+	g.AddEdge(1, 3, 1)
+	g.AddEdge(3, 4, 1)
+	g.AddEdge(2, 5, 1)
+	g.AddEdge(5, 4, 1)
+	g.AddEdge(2, 1, 1)
+
+	cost, path := g.VanillaTurnCostShortestPath(0, 4)
+	assert.Equal(t, 3.0, cost)
+	assert.Equal(t, []int64{0, 1, 3, 4}, path)
+
+	// Left turn across traffic
+	assert.NoError(t, g.AddTurnCost(0, 1, 3, 5))
+	cost, path = g.VanillaTurnCostShortestPath(0, 4)
+	assert.Equal(t, 4.0, cost)
+	assert.Equal(t, []int64{0, 1, 2, 5, 4}, path)
+	// Turn cost is applied only if path comes from 0
+	cost, path = g.VanillaTurnCostShortestPath(1, 4)
+	assert.Equal(t, 2.0, cost)
+	assert.Equal(t, []int64{1, 3, 4}, path)
+	// U-turn at 2 is cheaper than left turn
+	assert.NoError(t, g.AddTurnCost(2, 5, 4, 10))
+	cost, path = g.VanillaTurnCostShortestPath(0, 4)
+	assert.Equal(t, 5.0, cost)
+	assert.Equal(t, []int64{0, 1, 2, 1, 3, 4}, path)
+	// Turn after U-turn is forbidden
+	assert.NoError(t, g.AddTurnCost(2, 1, 3, Infinity))
+	cost, path = g.VanillaTurnCostShortestPath(0, 4)
+	assert.Equal(t, 8.0, cost)
+	assert.Equal(t, []int64{0, 1, 3, 4}, path)
+
+	ebg := g.PrepareEdgeBased(nil)
+	cost, path = ebg.ShortestPath(0, 4)
+	assert.Equal(t, 8.0, cost)
+	assert.Equal(t, []int64{0, 1, 3, 4}, path)
+
+	cost, path = g.VanillaTurnCostShortestPath(4, 0)
+	assert.Equal(t, -1.0, cost)
+	assert.Nil(t, path)
+}
+
+func TestAddTurnCostInvalid(t *testing.T) {
+	g := generateGridGraph(3)
+	err := g.AddTurnCost(0, 1, 2, -1)
+	assert.Equal(t, ErrInvalidTurnCost, errors.Cause(err))
+	err = g.AddTurnCost(0, 1, 100, 1)
+	assert.Equal(t, ErrVertexNotFound, errors.Cause(err))
+	err = g.AddTurnCost(0, 1, 8, 1)
+	assert.Equal(t, ErrEdgeNotFound, errors.Cause(err))
+	g.PrepareContractionHierarchies()
+	err = g.AddTurnCost(0, 1, 2, 1)
+	assert.Equal(t, ErrGraphIsFrozen, err)
+}
+
+func TestTurnCostsRandom(t *testing.T) {
+	const size = 6
+	g := generateGridGraph(size)
+	rnd := rand.New(rand.NewSource(7))
+	costs := make(map[[3]int64]float64)
+	for v := range g.Vertices {
+		for _, in := range g.Vertices[v].inIncidentEdges {
+			for _, out := range g.Vertices[v].outIncidentEdges {
+				turn := [3]int64{g.Vertices[in.vertexID].Label, g.Vertices[v].Label, g.Vertices[out.vertexID].Label}
+				cost := float64(rnd.Intn(4))
+				if rnd.Intn(10) == 0 {
+					cost = Infinity
+				}
+				assert.NoError(t, g.AddTurnCost(turn[0], turn[1], turn[2], cost))
+				costs[turn] = cost
+			}
+		}
+	}
+	restrictions := [][]int64{{0, 1, 2, 3}, {7, 8, 14}, {1, 7, 13}}
+	for _, restriction := range restrictions {
+		assert.NoError(t, g.AddMultiViaTurnRestriction(restriction[0], restriction[1:len(restriction)-1], restriction[len(restriction)-1]))
+	}
+	turnCost := func(from, via, to int64) float64 {
+		return costs[[3]int64{from, via, to}]
+	}
+
+	ebg := g.PrepareEdgeBased(nil)
+	for source := int64(0); source < size*size; source += 5 {
+		for target := int64(0); target < size*size; target += 2 {
+			expectedCost := bruteForceTurnPath(g, restrictions, turnCost, source, target)
+			if expectedCost >= Infinity {
+				expectedCost = -1
+			}
+			cost, _ := g.VanillaTurnCostShortestPath(source, target)
+			if math.Abs(expectedCost-cost) > eps {
+				t.Errorf("Cost of path %d -> %d should be %f, but got %f", source, target, expectedCost, cost)
+				return
+			}
+			cost, _ = ebg.ShortestPath(source, target)
+			if math.Abs(expectedCost-cost) > eps {
+				t.Errorf("Cost of path %d -> %d (edge-based CH) should be %f, but got %f", source, target, expectedCost, cost)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package ch
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// EdgeWeightUpdate New weight of edge identified by user's defined IDs of its vertices. See UpdateEdgeWeights(...)
+type EdgeWeightUpdate struct {
+	From   int64
+	To     int64
+	Weight float64
+}
+
+// BatchUpdateError Error of UpdateEdgeWeights(...): every update which can't be applied
+//
+// Errors - errors.Cause(err) of every error is ErrVertexNotFound or ErrEdgeNotFound
+type BatchUpdateError struct {
+	Errors []error
+}
+
+// Error Returns all errors joined by "; "
+func (err *BatchUpdateError) Error() string {
+	messages := make([]string, 0, len(err.Errors))
+	for _, e := range err.Errors {
+		messages = append(messages, e.Error())
+	}
+	return "Can't apply edge weight updates: " + strings.Join(messages, "; ")
+}
+
+// UpdateEdgeWeights Updates weights of multiple existing edges at once.
+// This function works with user-defined vertex labels.
+//
+// All updates are validated first: if any vertex or edge is not found then *BatchUpdateError listing all invalid updates is returned and graph is left unchanged.
+// Otherwise all weights are applied together (if the same edge is updated several times, the last weight wins) and, if CH has been prepared,
+// shortcuts are recustomized once: only the ones which depend on updated edges (see RecustomizeIncremental(...)).
+func (graph *Graph) UpdateEdgeWeights(updates []EdgeWeightUpdate) error {
+	internal := make([]EdgeKey, 0, len(updates))
+	var invalid []error
+	for i, update := range updates {
+		edge, err := graph.internalEdge(EdgeKey{From: update.From, To: update.To})
+		if err != nil {
+			invalid = append(invalid, errors.Wrapf(err, "Update #%d", i))
+			continue
+		}
+		internal = append(internal, edge)
+	}
+	if len(invalid) != 0 {
+		return &BatchUpdateError{Errors: invalid}
+	}
+
+	for i, edge := range internal {
+		graph.Vertices[edge.From].updateOutIncidentEdge(edge.To, updates[i].Weight)
+		graph.Vertices[edge.To].updateInIncidentEdge(edge.From, updates[i].Weight)
+	}
+	if graph.chPrepared {
+		graph.recustomizeIncremental(internal)
+		return nil
+	}
+	graph.publishChangedWeights(internal)
+	return nil
+}
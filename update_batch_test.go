@@ -0,0 +1,96 @@
+package ch
+
+import (
+	"math"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateEdgeWeights(t *testing.T) {
+	const size = 15
+	g := generateGridGraph(size)
+	g.PrepareContractionHierarchies()
+	expected := generateGridGraph(size)
+	expected.PrepareContractionHierarchies()
+
+	updates := []EdgeWeightUpdate{
+		{From: 0, To: 1, Weight: 7},
+		{From: 16, To: 17, Weight: 0.5},
+		{From: 112, To: 127, Weight: 9},
+		{From: 0, To: 1, Weight: 3},
+	}
+	err := g.UpdateEdgeWeights(updates)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, update := range updates {
+		err = expected.UpdateEdgeWeight(update.From, update.To, update.Weight, false)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	err = expected.Recustomize()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	from, _ := g.FindVertex(0)
+	to, _ := g.FindVertex(1)
+	assert.Equal(t, 3.0, g.getEdgeCost(from, to))
+	for source := int64(0); source < size*size; source += 23 {
+		for target := int64(0); target < size*size; target += 4 {
+			expectedCost, _ := expected.ShortestPath(source, target)
+			cost, _ := g.ShortestPath(source, target)
+			if math.Abs(expectedCost-cost) > eps {
+				t.Errorf("Cost of path %d -> %d should be %f, but got %f", source, target, expectedCost, cost)
+				return
+			}
+		}
+	}
+}
+
+func TestUpdateEdgeWeightsInvalid(t *testing.T) {
+	g := generateGridGraph(3)
+	g.PrepareContractionHierarchies()
+	costBefore, pathBefore := g.ShortestPath(0, 8)
+
+	err := g.UpdateEdgeWeights([]EdgeWeightUpdate{
+		{From: 0, To: 1, Weight: 100},
+		{From: 0, To: 100, Weight: 1},
+		{From: 1, To: 2, Weight: 100},
+		{From: 0, To: 8, Weight: 1},
+	})
+	batchErr, ok := err.(*BatchUpdateError)
+	if !ok {
+		t.Errorf("Error should be *BatchUpdateError, but got %v", err)
+		return
+	}
+	if assert.Len(t, batchErr.Errors, 2) {
+		assert.Equal(t, ErrVertexNotFound, errors.Cause(batchErr.Errors[0]))
+		assert.Equal(t, ErrEdgeNotFound, errors.Cause(batchErr.Errors[1]))
+	}
+	assert.Contains(t, err.Error(), "Update #1")
+	assert.Contains(t, err.Error(), "Update #3")
+
+	// Graph is left unchanged
+	from, _ := g.FindVertex(0)
+	to, _ := g.FindVertex(1)
+	assert.Equal(t, 1.0+float64((0*7+0*3)%5), g.getEdgeCost(from, to))
+	cost, path := g.ShortestPath(0, 8)
+	assert.Equal(t, costBefore, cost)
+	assert.Equal(t, pathBefore, path)
+}
+
+func TestUpdateEdgeWeightsNotPrepared(t *testing.T) {
+	g := generateGridGraph(3)
+	err := g.UpdateEdgeWeights([]EdgeWeightUpdate{{From: 0, To: 1, Weight: 100}})
+	assert.NoError(t, err)
+	from, _ := g.FindVertex(0)
+	to, _ := g.FindVertex(1)
+	assert.Equal(t, 100.0, g.getEdgeCost(from, to))
+}
@@ -0,0 +1,191 @@
+package ch
+
+import (
+	"container/heap"
+)
+
+// VanillaWidestPath Computes and returns widest (bottleneck) path and it's capacity: path which maximizes the minimum weight of its edges (modified Dijkstra's algorithm)
+//
+// If there are some errors (or there is no path at all) then function returns '-1.0' as capacity and nil as path
+//
+// source - User's definied ID of source vertex
+// target - User's definied ID of target vertex
+//
+// Weights of edges are treated as capacities. Capacity of path from vertex to itself is Infinity.
+//
+// https://en.wikipedia.org/wiki/Widest_path_problem
+func (graph *Graph) VanillaWidestPath(source, target int64) (float64, []int64) {
+	var ok bool
+	if source, ok = graph.mapping[source]; !ok {
+		return -1.0, nil
+	}
+	if target, ok = graph.mapping[target]; !ok {
+		return -1.0, nil
+	}
+	if source == target {
+		return Infinity, []int64{graph.Vertices[source].Label}
+	}
+
+	capacity := map[int64]float64{source: Infinity}
+	prev := make(map[int64]int64)
+	// Priorities are negated capacities, so min-heap pops the widest vertex first
+	queue := &minHeap{}
+	queue.add_with_priority(source, -Infinity)
+	for queue.Len() != 0 {
+		u := heap.Pop(queue).(*minHeapVertex)
+		if -u.distance < capacity[u.id] {
+			// Outdated item of queue
+			continue
+		}
+		if u.id == target {
+			break
+		}
+		for _, edge := range graph.originalOutIncidentEdges(u.id) {
+			neighbor := edge.vertexID
+			alt := capacity[u.id]
+			if edge.weight < alt {
+				alt = edge.weight
+			}
+			if c, ok := capacity[neighbor]; !ok || alt > c {
+				capacity[neighbor] = alt
+				prev[neighbor] = u.id
+				queue.add_with_priority(neighbor, -alt)
+			}
+		}
+	}
+	width, ok := capacity[target]
+	if !ok {
+		return -1.0, nil
+	}
+	path := []int64{graph.Vertices[target].Label}
+	for u := target; u != source; {
+		u = prev[u]
+		path = append(path, graph.Vertices[u].Label)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return width, path
+}
+
+// VanillaMaxCostPath Computes and returns the most expensive loopless path which has at most maxHops edges and it's cost
+//
+// If there are some errors (or there is no such path at all) then function returns '-1.0' as cost and nil as path
+//
+// source - User's definied ID of source vertex
+// target - User's definied ID of target vertex
+// maxHops - Maximum number of edges in path
+//
+// Walks with bounded number of edges are relaxed layer by layer (Bellman-Ford's algorithm for the longest walk) in O(maxHops*E) time.
+// If the most expensive walk is loopless (it is always so for DAG) then it is the answer. Otherwise depth-first search over loopless paths
+// with at most maxHops edges is used: it takes exponential time, so it is suitable for small hop limits or DAG-like subgraphs only.
+func (graph *Graph) VanillaMaxCostPath(source, target int64, maxHops int) (float64, []int64) {
+	if maxHops < 0 {
+		return -1.0, nil
+	}
+	var ok bool
+	if source, ok = graph.mapping[source]; !ok {
+		return -1.0, nil
+	}
+	if target, ok = graph.mapping[target]; !ok {
+		return -1.0, nil
+	}
+	if source == target {
+		// Any loopless path from vertex to itself is empty one
+		return 0, []int64{graph.Vertices[source].Label}
+	}
+	if maxHops > len(graph.Vertices)-1 {
+		// Loopless path can't have more edges
+		maxHops = len(graph.Vertices) - 1
+	}
+
+	cost, path := graph.maxCostWalk(source, target, maxHops)
+	if path == nil {
+		return -1.0, nil
+	}
+	visited := make(map[int64]struct{}, len(path))
+	for _, vertex := range path {
+		if _, ok := visited[vertex]; ok {
+			cost, path = graph.maxCostPathSearch(source, target, maxHops)
+			break
+		}
+		visited[vertex] = struct{}{}
+	}
+	labels := make([]int64, len(path))
+	for i, vertex := range path {
+		labels[i] = graph.Vertices[vertex].Label
+	}
+	return cost, labels
+}
+
+// maxCostWalk Returns the most expensive walk (library defined IDs of vertices) from source to target with at most maxHops edges. Returns nil if there is no such walk
+func (graph *Graph) maxCostWalk(source, target int64, maxHops int) (float64, []int64) {
+	// layers[h] - costs of the most expensive walks with exactly h edges, prevs[h] - previous vertices of them
+	layers := []map[int64]float64{{source: 0}}
+	prevs := []map[int64]int64{nil}
+	bestCost, bestHops := -1.0, -1
+	for h := 1; h <= maxHops && len(layers[h-1]) != 0; h++ {
+		layer := make(map[int64]float64)
+		prev := make(map[int64]int64)
+		for u, cost := range layers[h-1] {
+			if u == target {
+				// Walk can't pass target
+				continue
+			}
+			for _, edge := range graph.originalOutIncidentEdges(u) {
+				alt := cost + edge.weight
+				if c, ok := layer[edge.vertexID]; !ok || alt > c || (alt == c && u < prev[edge.vertexID]) {
+					layer[edge.vertexID] = alt
+					prev[edge.vertexID] = u
+				}
+			}
+		}
+		layers = append(layers, layer)
+		prevs = append(prevs, prev)
+		if cost, ok := layer[target]; ok && cost > bestCost {
+			bestCost, bestHops = cost, h
+		}
+	}
+	if bestHops < 0 {
+		return -1.0, nil
+	}
+	path := make([]int64, bestHops+1)
+	path[bestHops] = target
+	for h := bestHops; h > 0; h-- {
+		path[h-1] = prevs[h][path[h]]
+	}
+	return bestCost, path
+}
+
+// maxCostPathSearch Returns the most expensive loopless path (library defined IDs of vertices) from source to target with at most maxHops edges (depth-first search). Returns nil if there is no such path
+func (graph *Graph) maxCostPathSearch(source, target int64, maxHops int) (float64, []int64) {
+	bestCost := -1.0
+	var bestPath []int64
+	path := []int64{source}
+	visited := map[int64]bool{source: true}
+	var dfs func(vertex int64, cost float64)
+	dfs = func(vertex int64, cost float64) {
+		if vertex == target {
+			if cost > bestCost {
+				bestCost = cost
+				bestPath = append(bestPath[:0], path...)
+			}
+			return
+		}
+		if len(path) > maxHops {
+			return
+		}
+		for _, edge := range graph.originalOutIncidentEdges(vertex) {
+			if visited[edge.vertexID] {
+				continue
+			}
+			visited[edge.vertexID] = true
+			path = append(path, edge.vertexID)
+			dfs(edge.vertexID, cost+edge.weight)
+			path = path[:len(path)-1]
+			visited[edge.vertexID] = false
+		}
+	}
+	dfs(source, 0)
+	return bestCost, bestPath
+}
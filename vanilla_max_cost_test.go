@@ -0,0 +1,119 @@
+package ch
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVanillaWidestPath(t *testing.T) {
+	g := NewGraph()
+	for i := int64(1); i <= 5; i++ {
+		g.CreateVertex(i)
+	}
+	// Capacities of links
+	g.AddEdge(1, 2, 10)
+	g.AddEdge(2, 5, 3)
+	g.AddEdge(1, 3, 5)
+	g.AddEdge(3, 4, 7)
+	g.AddEdge(4, 5, 6)
+	g.AddEdge(2, 4, 4)
+
+	width, path := g.VanillaWidestPath(1, 5)
+	assert.Equal(t, 5.0, width)
+	assert.Equal(t, []int64{1, 3, 4, 5}, path)
+	width, path = g.VanillaWidestPath(2, 5)
+	assert.Equal(t, 4.0, width)
+	assert.Equal(t, []int64{2, 4, 5}, path)
+	width, path = g.VanillaWidestPath(1, 1)
+	assert.Equal(t, Infinity, width)
+	assert.Equal(t, []int64{1}, path)
+	width, path = g.VanillaWidestPath(5, 1)
+	assert.Equal(t, -1.0, width)
+	assert.Nil(t, path)
+	// Unknown vertex is not a path to itself
+	width, path = g.VanillaWidestPath(100, 100)
+	assert.Equal(t, -1.0, width)
+	assert.Nil(t, path)
+
+	// Shortcuts are ignored
+	g.PrepareContractionHierarchies()
+	width, path = g.VanillaWidestPath(1, 5)
+	assert.Equal(t, 5.0, width)
+	assert.Equal(t, []int64{1, 3, 4, 5}, path)
+}
+
+func TestVanillaMaxCostPath(t *testing.T) {
+	// DAG
+	g := NewGraph()
+	for i := int64(1); i <= 5; i++ {
+		g.CreateVertex(i)
+	}
+	g.AddEdge(1, 2, 1)
+	g.AddEdge(2, 3, 1)
+	g.AddEdge(3, 4, 1)
+	g.AddEdge(4, 5, 1)
+	g.AddEdge(1, 3, 5)
+	g.AddEdge(1, 5, 2)
+
+	cost, path := g.VanillaMaxCostPath(1, 5, 10)
+	assert.Equal(t, 7.0, cost)
+	assert.Equal(t, []int64{1, 3, 4, 5}, path)
+	cost, path = g.VanillaMaxCostPath(1, 5, 2)
+	assert.Equal(t, 2.0, cost)
+	assert.Equal(t, []int64{1, 5}, path)
+	cost, path = g.VanillaMaxCostPath(1, 5, 0)
+	assert.Equal(t, -1.0, cost)
+	assert.Nil(t, path)
+	cost, path = g.VanillaMaxCostPath(5, 1, 10)
+	assert.Equal(t, -1.0, cost)
+	assert.Nil(t, path)
+
+	// Cycle 2 -> 3 -> 2 makes the most expensive walk not loopless
+	g.AddEdge(3, 2, 10)
+	cost, path = g.VanillaMaxCostPath(1, 5, 10)
+	assert.Equal(t, 7.0, cost)
+	assert.Equal(t, []int64{1, 3, 4, 5}, path)
+}
+
+func TestVanillaMaxCostPathGrid(t *testing.T) {
+	const size = 3
+	g := generateGridGraph(size)
+	costs := allSimplePathCosts(g, 0, size*size-1)
+	cost, path := g.VanillaMaxCostPath(0, size*size-1, size*size)
+	if math.Abs(costs[len(costs)-1]-cost) > eps {
+		t.Errorf("Cost of path should be %f, but got %f", costs[len(costs)-1], cost)
+		return
+	}
+	visited := make(map[int64]struct{})
+	pathCost := 0.0
+	for i, vertex := range path {
+		_, ok := visited[vertex]
+		assert.False(t, ok, "Path %v has loop", path)
+		visited[vertex] = struct{}{}
+		if i > 0 {
+			from, _ := g.FindVertex(path[i-1])
+			to, _ := g.FindVertex(vertex)
+			pathCost += g.originalEdgeCost(from, to)
+		}
+	}
+	assert.InDelta(t, cost, pathCost, eps)
+}
+
+func TestVanillaMaxCostParallelShortcut(t *testing.T) {
+	// Shortcut 1 -> 2 (via 3) is parallel to initial edge 1 -> 2
+	g := parallelShortcutGraph(t)
+	width, path := g.VanillaWidestPath(1, 2)
+	assert.Equal(t, 10.0, width)
+	assert.Equal(t, []int64{1, 2}, path)
+	width, path = g.VanillaWidestPath(1, 4)
+	assert.Equal(t, 10.0, width)
+	assert.Equal(t, []int64{1, 2, 4}, path)
+	cost, path := g.VanillaMaxCostPath(1, 2, 5)
+	assert.Equal(t, 10.0, cost)
+	assert.Equal(t, []int64{1, 2}, path)
+	cost, path = g.VanillaMaxCostPath(1, 4, 5)
+	assert.Equal(t, 20.0, cost)
+	assert.Equal(t, []int64{1, 2, 4}, path)
+}
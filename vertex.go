@@ -13,6 +13,8 @@ type Vertex struct {
 	delNeighbors int
 	importance   int
 	contracted   bool
+	// Level of vertex in hierarchies: upper bound of search space depth (0 for vertices which neighbors have not been contracted yet)
+	level int
 	// Cached count of bidirected edges
 	bidirectedCount int
 	// Whether bidirectedCount is valid
@@ -51,6 +53,11 @@ func MakeVertex(label int64) *Vertex {
 
 // computeImportance Update importance of vertex
 func (vertex *Vertex) computeImportance() {
+	vertex.importance = vertex.defaultImportance()
+}
+
+// defaultImportance Returns importance of vertex computed by default heuristics
+func (vertex *Vertex) defaultImportance() int {
 	// Worst possible shortcuts number through the vertex is: NumWorstShortcuts = NumIncomingEdges*NumOutcomingEdges
 	shortcutCover := len(vertex.inIncidentEdges) * len(vertex.outIncidentEdges)
 	// Number of total incident edges is: NumIncomingEdges+NumOutcomingEdges
@@ -61,7 +68,7 @@ func (vertex *Vertex) computeImportance() {
 	// note: the more neighbours have already been contracted, the later this vertex will be contracted in further.
 	// [+] Bidirection edges heuristic: for each vertex check how many bidirected incident edges vertex has.
 	// note: the more bidirected incident edges == less important vertex is.
-	vertex.importance = edgeDiff + incidentEdgesNum + vertex.delNeighbors - vertex.bidirectedEdges()
+	return edgeDiff + incidentEdgesNum + vertex.delNeighbors - vertex.bidirectedEdges()
 }
 
 // bidirectedEdges Number of bidirected edges (cached)